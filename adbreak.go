@@ -0,0 +1,197 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines ad breaks of media playlists derived from SCTE-35
+ cues.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// adBreakTolerance is the allowed difference of durations in seconds
+// before they are reported as mismatched.
+const adBreakTolerance = 0.5
+
+// AdBreak groups the segments between the cue out and the cue in.
+// Segments of the break start from the segment with the cue out, the
+// segment with the cue in is the first one after the break.
+type AdBreak struct {
+	ID       string
+	CueOut   *SCTE // nil when the break started before the first segment of the playlist
+	CueIn    *SCTE // nil when the break is not ended yet
+	Segments []*MediaSegment
+	// PlannedDuration is the duration of the break announced by the
+	// cues in seconds, zero if unknown.
+	PlannedDuration float64
+	// Duration is the sum of durations of the break segments in the
+	// playlist.
+	Duration float64
+	// Elapsed is the time passed since the break start at the first
+	// segment of the break in the playlist. It is not zero only when
+	// the break started before the first segment.
+	Elapsed float64
+	// Offset is the media time offset of the first segment of the
+	// break from the start of the playlist.
+	Offset float64
+}
+
+// FirstSeqId returns the sequence number of the first segment of the
+// break.
+func (b *AdBreak) FirstSeqId() uint64 {
+	return b.Segments[0].SeqId
+}
+
+// LastSeqId returns the sequence number of the last segment of the
+// break.
+func (b *AdBreak) LastSeqId() uint64 {
+	return b.Segments[len(b.Segments)-1].SeqId
+}
+
+// Ended reports whether the break has the cue in.
+func (b *AdBreak) Ended() bool {
+	return b.CueIn != nil
+}
+
+// ElapsedAt returns the time passed since the break start at the start
+// of the segment with the sequence number.
+func (b *AdBreak) ElapsedAt(seqId uint64) (float64, error) {
+	elapsed := b.Elapsed
+	for _, seg := range b.Segments {
+		if seg.SeqId == seqId {
+			return elapsed, nil
+		}
+		elapsed += seg.Duration
+	}
+	return 0, ErrOutOfRange
+}
+
+// AdBreakError describes the malformed cue of the ad break.
+type AdBreakError struct {
+	SeqId  uint64 // sequence number of the segment with the cue
+	Reason string
+}
+
+func (e *AdBreakError) Error() string {
+	return fmt.Sprintf("segment %d: %s", e.SeqId, e.Reason)
+}
+
+// AdBreakErrors lists all malformed cues of the playlist.
+type AdBreakErrors []*AdBreakError
+
+func (e AdBreakErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AdBreaks groups segments of the playlist into ad breaks by their
+// SCTE-35 cues. Breaks are returned even for malformed cue sequences,
+// the problems are reported with the error of type AdBreakErrors:
+// cue out inside of a break, continuation or cue in outside of a
+// break, cue out without cue in in a closed playlist, continuation
+// durations and elapsed times which don't match the break.
+func (p *MediaPlaylist) AdBreaks() ([]*AdBreak, error) {
+	var (
+		breaks []*AdBreak
+		errs   AdBreakErrors
+		cur    *AdBreak
+	)
+	report := func(seg *MediaSegment, format string, args ...interface{}) {
+		errs = append(errs, &AdBreakError{SeqId: seg.SeqId, Reason: fmt.Sprintf(format, args...)})
+	}
+	for i, e := range p.Timeline() {
+		seg := e.Segment
+		if seg.SCTE != nil {
+			switch cueType(seg.SCTE) {
+			case SCTE35Cue_Start:
+				if cur != nil {
+					report(seg, "cue out inside of the break started at segment %d", cur.FirstSeqId())
+				}
+				cur = &AdBreak{ID: seg.SCTE.ID, CueOut: seg.SCTE, PlannedDuration: plannedDuration(seg.SCTE), Offset: e.Start}
+				breaks = append(breaks, cur)
+			case SCTE35Cue_Mid:
+				if cur == nil {
+					if i > 0 {
+						report(seg, "cue continuation outside of a break")
+					}
+					cur = &AdBreak{ID: seg.SCTE.ID, PlannedDuration: seg.SCTE.Time, Elapsed: seg.SCTE.Elapsed, Offset: e.Start}
+					breaks = append(breaks, cur)
+				}
+				if cur.PlannedDuration == 0 {
+					cur.PlannedDuration = seg.SCTE.Time
+				} else if seg.SCTE.Time != 0 && math.Abs(seg.SCTE.Time-cur.PlannedDuration) > adBreakTolerance {
+					report(seg, "cue continuation duration %v differs from planned %v", seg.SCTE.Time, cur.PlannedDuration)
+				}
+				if elapsed := cur.Elapsed + cur.Duration; seg.SCTE.Elapsed != 0 && math.Abs(seg.SCTE.Elapsed-elapsed) > adBreakTolerance {
+					report(seg, "cue continuation elapsed time %v differs from %v", seg.SCTE.Elapsed, elapsed)
+				}
+			case SCTE35Cue_End:
+				if cur == nil {
+					report(seg, "cue in outside of a break")
+					break
+				}
+				cur.CueIn = seg.SCTE
+				if cur.PlannedDuration != 0 && cur.CueOut != nil && math.Abs(cur.Duration-cur.PlannedDuration) > adBreakTolerance {
+					report(seg, "break duration %v differs from planned %v", cur.Duration, cur.PlannedDuration)
+				}
+				cur = nil
+			}
+		}
+		if cur != nil {
+			cur.Segments = append(cur.Segments, seg)
+			cur.Duration += seg.Duration
+		}
+	}
+	if cur != nil && p.Closed {
+		report(cur.Segments[0], "cue out without cue in")
+	}
+	if len(errs) > 0 {
+		return breaks, errs
+	}
+	return breaks, nil
+}
+
+// cueType returns the type of the cue. Tags of SCTE35_67_2014 syntax
+// don't define the type so it is derived from the cue itself.
+func cueType(scte *SCTE) SCTE35CueType {
+	if scte.Syntax == SCTE35_67_2014 {
+		return spliceCueType(scte.Cue)
+	}
+	return scte.CueType
+}
+
+// plannedDuration returns the duration of the break announced by the
+// cue out in seconds. The duration from the cue tag is preferred, the
+// break duration or the segmentation duration of the splice info is
+// used otherwise.
+func plannedDuration(scte *SCTE) float64 {
+	// TIME of SCTE35_67_2014 is not a duration
+	if scte.Time > 0 && scte.Syntax != SCTE35_67_2014 {
+		return scte.Time
+	}
+	info, err := scte.SpliceInfo()
+	if err != nil {
+		return 0
+	}
+	if info.SpliceInsert != nil && info.SpliceInsert.BreakDuration != nil {
+		return info.SpliceInsert.BreakDuration.Seconds()
+	}
+	for _, d := range info.Descriptors {
+		if d.Segmentation != nil && d.Segmentation.Duration != nil {
+			return d.Segmentation.DurationSeconds()
+		}
+	}
+	return 0
+}
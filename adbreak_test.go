@@ -0,0 +1,116 @@
+/*
+ Ad break tests.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestAdBreaks(t *testing.T) {
+	f, err := os.Open("sample-playlists/media-playlist-with-oatcls-scte35.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewMediaPlaylist(3, 3)
+	if err != nil {
+		t.Fatalf("Create media playlist failed: %s", err)
+	}
+	if err = p.DecodeFrom(bufio.NewReader(f), true); err != nil {
+		t.Fatal(err)
+	}
+	breaks, err := p.AdBreaks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(breaks) != 1 {
+		t.Fatalf("Expected 1 break, got: %d", len(breaks))
+	}
+	b := breaks[0]
+	if b.FirstSeqId() != 0 || b.LastSeqId() != 1 || !b.Ended() || b.CueOut != p.Segments[0].SCTE || b.CueIn != p.Segments[2].SCTE {
+		t.Errorf("Unexpected break: %+v", b)
+	}
+	if b.PlannedDuration != 15 || b.Duration != 15 || b.Elapsed != 0 || b.Offset != 0 {
+		t.Errorf("Unexpected break durations: %v/%v/%v/%v", b.PlannedDuration, b.Duration, b.Elapsed, b.Offset)
+	}
+	if elapsed, err := b.ElapsedAt(1); err != nil || elapsed != 8.844 {
+		t.Errorf("Unexpected elapsed time: %v (%v)", elapsed, err)
+	}
+	if _, err := b.ElapsedAt(2); err != ErrOutOfRange {
+		t.Errorf("Expected ErrOutOfRange, got: %v", err)
+	}
+}
+
+// Check the break started before the first segment of a live window
+// and the planned duration taken from the splice info.
+func TestAdBreaksLiveWindow(t *testing.T) {
+	p, _ := NewMediaPlaylist(4, 4)
+	p.SeqNo = 10
+	for i := 0; i < 4; i++ {
+		_ = p.Append(fmt.Sprintf("test%d.ts", i), 5.0, "")
+	}
+	p.Segments[0].SCTE = &SCTE{Syntax: SCTE35_OATCLS, CueType: SCTE35Cue_Mid, Time: 20, Elapsed: 10}
+	p.Segments[1].SCTE = &SCTE{Syntax: SCTE35_OATCLS, CueType: SCTE35Cue_Mid, Time: 20, Elapsed: 15}
+	p.Segments[2].SCTE = &SCTE{Syntax: SCTE35_OATCLS, CueType: SCTE35Cue_End}
+	p.Segments[3].SCTE = &SCTE{Syntax: SCTE35_67_2014, Cue: "/DAlAAAAAAAAAP/wFAUAAAABf+/+ANgNkv4AFJlwAAEBAQAA5xULLA=="}
+	breaks, err := p.AdBreaks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(breaks) != 2 {
+		t.Fatalf("Expected 2 breaks, got: %d", len(breaks))
+	}
+	if b := breaks[0]; b.CueOut != nil || b.Elapsed != 10 || b.FirstSeqId() != 10 || b.LastSeqId() != 11 || !b.Ended() {
+		t.Errorf("Unexpected first break: %+v", b)
+	}
+	if b := breaks[1]; b.PlannedDuration != 15 || b.Ended() || b.Offset != 15 {
+		t.Errorf("Unexpected second break: %+v", b)
+	}
+}
+
+func TestAdBreaksMalformed(t *testing.T) {
+	p, _ := NewMediaPlaylist(0, 7)
+	for i := 0; i < 7; i++ {
+		_ = p.Append(fmt.Sprintf("test%d.ts", i), 5.0, "")
+	}
+	p.Segments[0].SCTE = &SCTE{Syntax: SCTE35_OATCLS, CueType: SCTE35Cue_End}
+	p.Segments[1].SCTE = &SCTE{Syntax: SCTE35_OATCLS, CueType: SCTE35Cue_Start, Time: 30}
+	p.Segments[2].SCTE = &SCTE{Syntax: SCTE35_OATCLS, CueType: SCTE35Cue_Mid, Time: 20, Elapsed: 5}
+	p.Segments[3].SCTE = &SCTE{Syntax: SCTE35_OATCLS, CueType: SCTE35Cue_End}
+	p.Segments[4].SCTE = &SCTE{Syntax: SCTE35_OATCLS, CueType: SCTE35Cue_Mid, Time: 10}
+	p.Segments[5].SCTE = &SCTE{Syntax: SCTE35_ELEMENTAL, CueType: SCTE35Cue_Start, Time: 10}
+	p.Close()
+	breaks, err := p.AdBreaks()
+	if len(breaks) != 3 {
+		t.Errorf("Expected 3 breaks, got: %d", len(breaks))
+	}
+	errs, ok := err.(AdBreakErrors)
+	if !ok {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{
+		"segment 0: cue in outside of a break",
+		"segment 2: cue continuation duration 20 differs from planned 30",
+		"segment 3: break duration 10 differs from planned 30",
+		"segment 4: cue continuation outside of a break",
+		"segment 5: cue out inside of the break started at segment 4",
+		"segment 5: cue out without cue in",
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("Unexpected errors: %v", err)
+	}
+	for i, e := range errs {
+		if e.Error() != expected[i] {
+			t.Errorf("Expected %q, got: %q", expected[i], e)
+		}
+	}
+}
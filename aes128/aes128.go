@@ -0,0 +1,105 @@
+// Package aes128 encrypts and decrypts media segments of HLS playlists
+// with METHOD=AES-128: whole segments are encrypted with AES-128 in
+// CBC mode with PKCS7 padding. IV of a segment is taken from the IV
+// attribute of its key or derived from the media sequence number of
+// the segment when the attribute is absent.
+package aes128
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines encryption of segments with AES-128.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+
+	"github.com/khenarghot/m3u8"
+)
+
+// ErrInvalidPadding is returned when the decrypted data has no valid
+// PKCS7 padding, usually because of the wrong key or IV.
+var ErrInvalidPadding = errors.New("invalid PKCS7 padding")
+
+// Encrypt encrypts the data with the 16 bytes key and IV in CBC mode
+// with PKCS7 padding.
+func Encrypt(data, key []byte, iv [16]byte) ([]byte, error) {
+	block, err := newCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	n := aes.BlockSize - len(data)%aes.BlockSize
+	out := make([]byte, len(data)+n)
+	copy(out, data)
+	copy(out[len(data):], bytes.Repeat([]byte{byte(n)}, n))
+	cipher.NewCBCEncrypter(block, iv[:]).CryptBlocks(out, out)
+	return out, nil
+}
+
+// Decrypt decrypts the data encrypted with the 16 bytes key and IV in
+// CBC mode with PKCS7 padding.
+func Decrypt(data, key []byte, iv [16]byte) ([]byte, error) {
+	block, err := newCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, errors.New("encrypted data is not a multiple of the block size")
+	}
+	out := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv[:]).CryptBlocks(out, data)
+	n := int(out[len(out)-1])
+	if n == 0 || n > aes.BlockSize {
+		return nil, ErrInvalidPadding
+	}
+	for _, b := range out[len(out)-n:] {
+		if int(b) != n {
+			return nil, ErrInvalidPadding
+		}
+	}
+	return out[:len(out)-n], nil
+}
+
+// EncryptSegment encrypts the data of the segment with the sequence
+// number. The key tag defines IV, the key itself is the content of the
+// key URI.
+func EncryptSegment(data, key []byte, xkey *m3u8.Key, seqId uint64) ([]byte, error) {
+	iv, err := segmentIV(xkey, seqId)
+	if err != nil {
+		return nil, err
+	}
+	return Encrypt(data, key, iv)
+}
+
+// DecryptSegment decrypts the data of the segment with the sequence
+// number. The key tag defines IV, the key itself is the content of the
+// key URI.
+func DecryptSegment(data, key []byte, xkey *m3u8.Key, seqId uint64) ([]byte, error) {
+	iv, err := segmentIV(xkey, seqId)
+	if err != nil {
+		return nil, err
+	}
+	return Decrypt(data, key, iv)
+}
+
+func newCipher(key []byte) (cipher.Block, error) {
+	if len(key) != 16 {
+		return nil, errors.New("AES-128 key must be 16 bytes long")
+	}
+	return aes.NewCipher(key)
+}
+
+func segmentIV(xkey *m3u8.Key, seqId uint64) ([16]byte, error) {
+	if xkey == nil || xkey.Method != m3u8.KeyMethodAES128 {
+		return [16]byte{}, errors.New("key method must be AES-128")
+	}
+	return xkey.IVFor(seqId)
+}
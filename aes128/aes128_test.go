@@ -0,0 +1,88 @@
+/*
+ AES-128 segment encryption tests.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+package aes128
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/khenarghot/m3u8"
+)
+
+func TestEncrypt(t *testing.T) {
+	// NIST SP 800-38A F.2.1 CBC-AES128.Encrypt, the first block
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	data, _ := hex.DecodeString("6bc1bee22e409f96e93d7e117393172a")
+	var iv [16]byte
+	for i := range iv {
+		iv[i] = byte(i)
+	}
+	out, err := Encrypt(data, key, iv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 32 {
+		t.Fatalf("Expected the padding block, got %d bytes", len(out))
+	}
+	if got := hex.EncodeToString(out[:16]); got != "7649abac8119b246cee98e9b12e9197d" {
+		t.Errorf("Unexpected cipher text: %s", got)
+	}
+	plain, err := Decrypt(out, key, iv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plain, data) {
+		t.Errorf("Expected %x, got %x", data, plain)
+	}
+	if _, err = Encrypt(data, key[:8], iv); err == nil {
+		t.Error("Expected error for short key")
+	}
+}
+
+func TestDecryptWithWrongKey(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	out, err := Encrypt([]byte("segment"), key, [16]byte{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Decrypt(out, []byte("fedcba9876543210"), [16]byte{}); err != ErrInvalidPadding {
+		t.Errorf("Expected invalid padding, got %v", err)
+	}
+	if _, err = Decrypt(out[:10], key, [16]byte{}); err == nil {
+		t.Error("Expected error for truncated data")
+	}
+}
+
+func TestSegmentIV(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	data := bytes.Repeat([]byte{0x47}, 188)
+	xkey, _ := m3u8.NewAES128Key("key.bin", "")
+	out, err := EncryptSegment(data, key, xkey, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the implicit IV is the sequence number
+	expected, _ := Encrypt(data, key, m3u8.ImplicitIV(7))
+	if !bytes.Equal(out, expected) {
+		t.Error("Segment is not encrypted with the implicit IV")
+	}
+	plain, err := DecryptSegment(out, key, xkey, 7)
+	if err != nil || !bytes.Equal(plain, data) {
+		t.Errorf("Decrypt segment failed: %v", err)
+	}
+	xkey.IV = "0x000102030405060708090A0B0C0D0E0F"
+	if plain, err = DecryptSegment(out, key, xkey, 7); err == nil && bytes.Equal(plain, data) {
+		t.Error("Expected explicit IV to be used")
+	}
+	if _, err = EncryptSegment(data, key, &m3u8.Key{Method: "SAMPLE-AES", URI: "key.bin"}, 7); err == nil {
+		t.Error("Expected error for SAMPLE-AES key")
+	}
+}
@@ -0,0 +1,129 @@
+package aes128
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines encryption of media playlists with their segments
+ stored in local directories.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/khenarghot/m3u8"
+)
+
+// EncryptPlaylist encrypts the segments of the media playlist found in
+// the source directory and writes them to the destination directory
+// under the same relative paths. It returns the copy of the playlist
+// with the default key of METHOD=AES-128 which points to the key URI.
+// Segments are encrypted with implicit IVs derived from their sequence
+// numbers. URIs of segments must be relative paths inside of the
+// source directory, encrypted segments, byte ranges and media
+// initialization sections (EXT-X-MAP) are not supported.
+func EncryptPlaylist(p *m3u8.MediaPlaylist, srcDir, dstDir string, key []byte, keyURI string) (*m3u8.MediaPlaylist, error) {
+	xkey, err := m3u8.NewAES128Key(keyURI, "")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = newCipher(key); err != nil {
+		return nil, err
+	}
+	out := p.Clone()
+	if out.Key != nil && out.Key.Method != m3u8.KeyMethodNone {
+		return nil, errors.New("playlist is already encrypted")
+	}
+	if out.Map != nil {
+		return nil, errors.New("media initialization sections are not supported")
+	}
+	for _, seg := range out.OrderedSegments() {
+		if seg.Key != nil && seg.Key.Method != m3u8.KeyMethodNone {
+			return nil, fmt.Errorf("segment %d is already encrypted", seg.SeqId)
+		}
+		if seg.Map != nil {
+			return nil, fmt.Errorf("segment %d: media initialization sections are not supported", seg.SeqId)
+		}
+		if seg.Limit > 0 {
+			return nil, fmt.Errorf("segment %d: byte ranges are not supported", seg.SeqId)
+		}
+		name, err := segmentPath(seg.URI)
+		if err != nil {
+			return nil, fmt.Errorf("segment %d: %s", seg.SeqId, err)
+		}
+		data, err := ioutil.ReadFile(filepath.Join(srcDir, name))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = EncryptSegment(data, key, xkey, seg.SeqId); err != nil {
+			return nil, err
+		}
+		dst := filepath.Join(dstDir, name)
+		if err = os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return nil, err
+		}
+		if err = ioutil.WriteFile(dst, data, 0644); err != nil {
+			return nil, err
+		}
+		seg.Key, seg.Keys = nil, nil
+	}
+	out.Key, out.Keys = xkey, nil
+	out.ResetCache()
+	return out, nil
+}
+
+// EncryptDir decodes the media playlist with the name from the source
+// directory, encrypts it with EncryptPlaylist and writes the rewritten
+// playlist with the same name to the destination directory. The key
+// itself is not written, it must be published at the key URI.
+func EncryptDir(srcDir, dstDir, name string, key []byte, keyURI string) error {
+	f, err := os.Open(filepath.Join(srcDir, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	p, listType, err := m3u8.DecodeFrom(bufio.NewReader(f), true)
+	if err != nil {
+		return err
+	}
+	if listType != m3u8.MEDIA {
+		return errors.New("not a media playlist")
+	}
+	out, err := EncryptPlaylist(p.(*m3u8.MediaPlaylist), srcDir, dstDir, key, keyURI)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(dstDir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dstDir, name), out.Encode().Bytes(), 0644)
+}
+
+// segmentPath returns the local path of the segment URI relative to
+// the playlist directory.
+func segmentPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "" || u.Host != "" || path.IsAbs(u.Path) {
+		return "", errors.New("URI is not a relative path")
+	}
+	name := path.Clean(u.Path)
+	if name == ".." || strings.HasPrefix(name, "../") {
+		return "", errors.New("URI is outside of the playlist directory")
+	}
+	return filepath.FromSlash(name), nil
+}
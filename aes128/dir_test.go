@@ -0,0 +1,112 @@
+/*
+ Playlist encryption tests.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+package aes128
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/khenarghot/m3u8"
+)
+
+func TestEncryptDir(t *testing.T) {
+	srcDir, err := ioutil.TempDir("", "m3u8-src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(srcDir)
+	dstDir, err := ioutil.TempDir("", "m3u8-dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dstDir)
+
+	p, _ := m3u8.NewMediaPlaylist(3, 3)
+	p.SeqNo = 10
+	if err = os.Mkdir(filepath.Join(srcDir, "hd"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		uri := fmt.Sprintf("hd/seg%d.ts", i)
+		_ = p.Append(uri, 4, "")
+		data := bytes.Repeat([]byte{byte(i)}, 100+i)
+		if err = ioutil.WriteFile(filepath.Join(srcDir, uri), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p.Close()
+	if err = ioutil.WriteFile(filepath.Join(srcDir, "index.m3u8"), p.Encode().Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	key := []byte("0123456789abcdef")
+	if err = EncryptDir(srcDir, dstDir, "index.m3u8", key, "https://example.com/key.bin"); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(filepath.Join(dstDir, "index.m3u8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	out, _ := m3u8.NewMediaPlaylist(3, 3)
+	if err = out.DecodeFrom(bufio.NewReader(f), true); err != nil {
+		t.Fatal(err)
+	}
+	if out.Key == nil || out.Key.Method != "AES-128" || out.Key.URI != "https://example.com/key.bin" || out.Key.IV != "" {
+		t.Fatalf("Unexpected key of the encrypted playlist: %+v", out.Key)
+	}
+	for i, seg := range out.OrderedSegments() {
+		data, err := ioutil.ReadFile(filepath.Join(dstDir, filepath.FromSlash(seg.URI)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		plain, err := DecryptSegment(data, key, seg.Key, seg.SeqId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(plain, bytes.Repeat([]byte{byte(i)}, 100+i)) {
+			t.Errorf("Segment %d is not decrypted", seg.SeqId)
+		}
+	}
+}
+
+func TestEncryptPlaylistRejectsURIs(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	for _, uri := range []string{"https://example.com/seg.ts", "/seg.ts", "../seg.ts"} {
+		p, _ := m3u8.NewMediaPlaylist(1, 1)
+		_ = p.Append(uri, 4, "")
+		if _, err := EncryptPlaylist(p, "src", "dst", key, "key.bin"); err == nil {
+			t.Errorf("Expected error for URI %s", uri)
+		}
+	}
+	p, _ := m3u8.NewMediaPlaylist(1, 1)
+	_ = p.Append("seg.ts", 4, "")
+	_ = p.SetKey("AES-128", "old.bin", "", "", "")
+	if _, err := EncryptPlaylist(p, "src", "dst", key, "key.bin"); err == nil {
+		t.Error("Expected error for encrypted segment")
+	}
+	p, _ = m3u8.NewMediaPlaylist(1, 1)
+	_ = p.Append("seg.m4s", 4, "")
+	_ = p.SetMap("init.mp4", 0, 0)
+	if _, err := EncryptPlaylist(p, "src", "dst", key, "key.bin"); err == nil {
+		t.Error("Expected error for segment with map")
+	}
+	p, _ = m3u8.NewMediaPlaylist(1, 1)
+	_ = p.Append("seg.m4s", 4, "")
+	p.SetDefaultMap("init.mp4", 0, 0)
+	if _, err := EncryptPlaylist(p, "src", "dst", key, "key.bin"); err == nil {
+		t.Error("Expected error for playlist with default map")
+	}
+}
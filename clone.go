@@ -0,0 +1,228 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines functions related to deep copying of playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"net/url"
+)
+
+// cloner keeps already copied objects so pointers shared in the
+// original playlist stay shared in the copy. It matters for
+// RewriteURIs which visits each shared key, map or alternative once.
+type cloner struct {
+	keys  map[*Key]*Key
+	maps  map[*Map]*Map
+	alts  map[*Alternative]*Alternative
+	media map[*MediaPlaylist]*MediaPlaylist
+}
+
+func newCloner() *cloner {
+	return &cloner{
+		keys:  make(map[*Key]*Key),
+		maps:  make(map[*Map]*Map),
+		alts:  make(map[*Alternative]*Alternative),
+		media: make(map[*MediaPlaylist]*MediaPlaylist),
+	}
+}
+
+// Clone returns a deep copy of the master playlist. Variants,
+// alternative renditions and linked chunklists are copied so the
+// copy may be changed without affecting the original. Values of
+// custom tags are interfaces and they are shared with the original.
+func (p *MasterPlaylist) Clone() *MasterPlaylist {
+	return newCloner().master(p)
+}
+
+// Clone returns a deep copy of the media playlist including its
+// segments, keys, maps, SCTE cues and Widevine tags. The copy may be
+// changed without affecting the original. Values of custom tags are
+// interfaces and they are shared with the original.
+func (p *MediaPlaylist) Clone() *MediaPlaylist {
+	return newCloner().mediaPlaylist(p)
+}
+
+func (c *cloner) master(p *MasterPlaylist) *MasterPlaylist {
+	if p == nil {
+		return nil
+	}
+	cp := NewMasterPlaylist()
+	cp.Args = p.Args
+	cp.CypherVersion = p.CypherVersion
+	cp.ver = p.ver
+	cp.independentSegments = p.independentSegments
+	cp.Custom = cloneCustom(p.Custom)
+	cp.BaseURL = cloneURL(p.BaseURL)
+	cp.customDecoders = append([]CustomDecoder(nil), p.customDecoders...)
+	if p.Variants != nil {
+		cp.Variants = make([]*Variant, len(p.Variants))
+	}
+	for i, v := range p.Variants {
+		if v == nil {
+			continue
+		}
+		nv := *v
+		nv.Chunklist = c.mediaPlaylist(v.Chunklist)
+		if v.Alternatives != nil {
+			nv.Alternatives = make([]*Alternative, len(v.Alternatives))
+			for j, alt := range v.Alternatives {
+				nv.Alternatives[j] = c.alternative(alt)
+			}
+		}
+		nv.CodecList = append([]Codec(nil), v.CodecList...)
+		cp.Variants[i] = &nv
+	}
+	return cp
+}
+
+func (c *cloner) mediaPlaylist(p *MediaPlaylist) *MediaPlaylist {
+	if p == nil {
+		return nil
+	}
+	if cp, ok := c.media[p]; ok {
+		return cp
+	}
+	cp := new(MediaPlaylist)
+	c.media[p] = cp
+	cp.TargetDuration = p.TargetDuration
+	cp.SeqNo = p.SeqNo
+	cp.Args = p.Args
+	cp.Iframe = p.Iframe
+	cp.Closed = p.Closed
+	cp.MediaType = p.MediaType
+	cp.DiscontinuitySeq = p.DiscontinuitySeq
+	cp.StartTime = p.StartTime
+	cp.StartTimePrecise = p.StartTimePrecise
+	cp.durationAsInt = p.durationAsInt
+	cp.targetRounding = p.targetRounding
+	cp.keyformat = p.keyformat
+	cp.winsize = p.winsize
+	cp.capacity = p.capacity
+	cp.head = p.head
+	cp.tail = p.tail
+	cp.count = p.count
+	cp.ver = p.ver
+	cp.Key, cp.Keys = c.key(p.Key), c.keyList(p.Keys)
+	cp.Map = c.xmap(p.Map)
+	if p.WV != nil {
+		wv := *p.WV
+		cp.WV = &wv
+	}
+	cp.Custom = cloneCustom(p.Custom)
+	cp.BaseURL = cloneURL(p.BaseURL)
+	cp.customDecoders = append([]CustomDecoder(nil), p.customDecoders...)
+	if p.autoPDT != nil {
+		autoPDT := *p.autoPDT
+		cp.autoPDT = &autoPDT
+	}
+	// only segments in the FIFO are copied, the rest of the slice
+	// keeps nils in the copy
+	cp.Segments = make([]*MediaSegment, len(p.Segments))
+	head := p.head
+	for count := p.count; count > 0 && p.capacity > 0; count-- {
+		cp.Segments[head] = c.segment(p.Segments[head])
+		head = (head + 1) % p.capacity
+	}
+	if p.rotation != nil {
+		rotation := *p.rotation
+		rotation.key, rotation.last = c.key(rotation.key), nil
+		if p.count > 0 && p.Segments[p.last()] == p.rotation.last {
+			rotation.last = cp.Segments[p.last()]
+		}
+		cp.rotation = &rotation
+	}
+	return cp
+}
+
+func (c *cloner) segment(seg *MediaSegment) *MediaSegment {
+	if seg == nil {
+		return nil
+	}
+	cp := *seg
+	cp.Key, cp.Keys = c.key(seg.Key), c.keyList(seg.Keys)
+	cp.Map = c.xmap(seg.Map)
+	if seg.SCTE != nil {
+		scte := *seg.SCTE
+		cp.SCTE = &scte
+	}
+	cp.Custom = cloneCustom(seg.Custom)
+	return &cp
+}
+
+func (c *cloner) key(key *Key) *Key {
+	if key == nil {
+		return nil
+	}
+	if cp, ok := c.keys[key]; ok {
+		return cp
+	}
+	cp := *key
+	c.keys[key] = &cp
+	return &cp
+}
+
+func (c *cloner) keyList(keys []*Key) []*Key {
+	if keys == nil {
+		return nil
+	}
+	cp := make([]*Key, len(keys))
+	for i, key := range keys {
+		cp[i] = c.key(key)
+	}
+	return cp
+}
+
+func (c *cloner) xmap(xmap *Map) *Map {
+	if xmap == nil {
+		return nil
+	}
+	if cp, ok := c.maps[xmap]; ok {
+		return cp
+	}
+	cp := *xmap
+	c.maps[xmap] = &cp
+	return &cp
+}
+
+func (c *cloner) alternative(alt *Alternative) *Alternative {
+	if alt == nil {
+		return nil
+	}
+	if cp, ok := c.alts[alt]; ok {
+		return cp
+	}
+	cp := *alt
+	c.alts[alt] = &cp
+	return &cp
+}
+
+func cloneCustom(custom map[string]CustomTag) map[string]CustomTag {
+	if custom == nil {
+		return nil
+	}
+	cp := make(map[string]CustomTag, len(custom))
+	for k, v := range custom {
+		cp[k] = v
+	}
+	return cp
+}
+
+func cloneURL(u *url.URL) *url.URL {
+	if u == nil {
+		return nil
+	}
+	cp := *u
+	if u.User != nil {
+		user := *u.User
+		cp.User = &user
+	}
+	return &cp
+}
@@ -0,0 +1,109 @@
+/*
+ Playlist deep copy tests.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestCloneMediaPlaylist(t *testing.T) {
+	p, e := NewMediaPlaylist(3, 5)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	p.SetDefaultKey("AES-128", "default.key", "", "", "")
+	for i := 0; i < 7; i++ {
+		p.Slide(fmt.Sprintf("test%d.ts", i), 5.0, "")
+	}
+	_ = p.SetKey("AES-128", "next.key", "", "", "")
+	_ = p.SetMap("init.mp4", 0, 0)
+	_ = p.SetSCTE35(&SCTE{Syntax: SCTE35_OATCLS, CueType: SCTE35Cue_Start, Cue: "/DAl", Time: 30})
+	p.SetCustomTag(&MockCustomTag{name: "#TAG", encodedString: "#TAG"})
+	expected := p.String()
+
+	c := p.Clone()
+	if c.String() != expected {
+		t.Fatalf("Encoded copy differs from the original:\n%s\nexpected:\n%s", c.String(), expected)
+	}
+	if c.head != p.head || c.tail != p.tail || c.count != p.count || c.capacity != p.capacity {
+		t.Errorf("Ring buffer state was not copied: %d/%d/%d/%d", c.head, c.tail, c.count, c.capacity)
+	}
+
+	last := c.Segments[c.last()]
+	last.URI = "changed.ts"
+	last.Key.URI = "changed.key"
+	last.Map.URI = "changed.mp4"
+	last.SCTE.Cue = "changed"
+	c.Key.URI = "changed-default.key"
+	delete(c.Custom, "#TAG")
+	c.Slide("new.ts", 5.0, "")
+	p.ResetCache()
+	if p.String() != expected {
+		t.Errorf("Changes of the copy affected the original:\n%s\nexpected:\n%s", p.String(), expected)
+	}
+}
+
+func TestCloneMediaPlaylistKeepsSharedKeys(t *testing.T) {
+	p, e := NewMediaPlaylist(0, 3)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	p.SetDefaultKey("AES-128", "default.key", "", "", "")
+	_ = p.AppendSegment(&MediaSegment{URI: "test0.ts", Duration: 5, Key: p.Key})
+	_ = p.AppendSegment(&MediaSegment{URI: "test1.ts", Duration: 5, Key: p.Key})
+	c := p.Clone()
+	if c.Key == p.Key {
+		t.Fatal("Default key of the copy points to the original")
+	}
+	if c.Segments[0].Key != c.Key || c.Segments[1].Key != c.Key {
+		t.Error("Shared key must stay shared in the copy")
+	}
+	if c.String() != p.String() {
+		t.Errorf("Encoded copy differs from the original:\n%s", c.String())
+	}
+}
+
+func TestCloneMasterPlaylist(t *testing.T) {
+	f, err := os.Open("sample-playlists/master-with-alternatives.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewMasterPlaylist()
+	if err = p.DecodeFrom(bufio.NewReader(f), false); err != nil {
+		t.Fatal(err)
+	}
+	chunklist, _ := NewMediaPlaylist(3, 3)
+	_ = chunklist.Append("test.ts", 5, "")
+	p.Variants[0].Chunklist = chunklist
+	p.Variants[1].Chunklist = chunklist
+	expected := p.String()
+
+	c := p.Clone()
+	if c.String() != expected {
+		t.Fatalf("Encoded copy differs from the original:\n%s\nexpected:\n%s", c.String(), expected)
+	}
+	if c.Variants[0].Chunklist == chunklist || c.Variants[0].Chunklist != c.Variants[1].Chunklist {
+		t.Error("Chunklist must be copied once and stay shared between variants")
+	}
+	c.Variants[0].URI = "changed.m3u8"
+	c.Variants[0].Alternatives[0].Name = "Changed"
+	c.Variants[0].Chunklist.Segments[0].URI = "changed.ts"
+	c.Append("new.m3u8", nil, VariantParams{Bandwidth: 1})
+	p.ResetCache()
+	if p.String() != expected {
+		t.Errorf("Changes of the copy affected the original:\n%s\nexpected:\n%s", p.String(), expected)
+	}
+	if chunklist.Segments[0].URI != "test.ts" {
+		t.Errorf("Changes of the copy affected the original chunklist: %s", chunklist.Segments[0].URI)
+	}
+}
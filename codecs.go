@@ -0,0 +1,393 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines parsing and formatting of RFC 6381 codec strings of
+ CODECS attribute.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MediaKind is the kind of media described by a codec.
+type MediaKind int
+
+const (
+	MediaUnknown MediaKind = iota
+	MediaVideo
+	MediaAudio
+	MediaText
+)
+
+func (k MediaKind) String() string {
+	switch k {
+	case MediaVideo:
+		return "video"
+	case MediaAudio:
+		return "audio"
+	case MediaText:
+		return "text"
+	}
+	return "unknown"
+}
+
+// Codec is an entry of CODECS attribute in the form of RFC 6381. Typed
+// fields are used according to the sample entry type, fields which
+// don't apply to the type are zero.
+type Codec struct {
+	// Type is the sample entry type: avc1, avc3, hvc1, hev1, av01,
+	// vp09, dvh1, dvhe, dva1, dvav, dav1, mp4a, ac-3, ec-3 and so on.
+	Type string
+	// Profile is profile_idc of AVC and HEVC, seq_profile of AV1,
+	// profile of VP9 and Dolby Vision, audio object type of mp4a.
+	Profile int
+	// Constraints are constraint_set flags of AVC.
+	Constraints uint8
+	// Compatibility are general_profile_compatibility_flags of HEVC
+	// in the order of the codec string. Zero means the flag of the
+	// profile itself.
+	Compatibility uint32
+	// Tier is L or H for HEVC, M or H for AV1.
+	Tier string
+	// Level is level_idc of AVC (10 × level) and HEVC (30 × level),
+	// seq_level_idx of AV1, level of VP9 (10 × level) and Dolby
+	// Vision.
+	Level int
+	// BitDepth of AV1 and VP9.
+	BitDepth int
+	// ObjectType is the object type indication of mp4a, 0x40 for
+	// MPEG-4 audio.
+	ObjectType int
+	// Extra keeps the optional trailing fields as is: constraint
+	// indicator flags of HEVC, color and chroma fields of AV1 and VP9,
+	// the parameters of other types.
+	Extra string
+
+	raw string // the entry which could not be parsed
+}
+
+// Kind returns the kind of media of the codec.
+func (c Codec) Kind() MediaKind {
+	switch c.Type {
+	case "avc1", "avc3", "hvc1", "hev1", "av01", "vp08", "vp09", "dvh1", "dvhe", "dva1", "dvav", "dav1":
+		return MediaVideo
+	case "mp4a", "ac-3", "ec-3", "ac-4", "opus", "Opus", "fLaC", "alac":
+		return MediaAudio
+	case "stpp", "wvtt", "tx3g":
+		return MediaText
+	}
+	return MediaUnknown
+}
+
+// Family returns the codec family of the sample entry type: avc,
+// hevc, av1, vp9, dolby-vision, aac, mp3, ac-3, ec-3 and the type
+// itself for others. Sample entries of the same family describe the
+// same codec.
+func (c Codec) Family() string {
+	switch c.Type {
+	case "avc1", "avc3":
+		return "avc"
+	case "hvc1", "hev1":
+		return "hevc"
+	case "av01":
+		return "av1"
+	case "vp09":
+		return "vp9"
+	case "dvh1", "dvhe", "dva1", "dvav", "dav1":
+		return "dolby-vision"
+	case "mp4a":
+		if c.mp3() {
+			return "mp3"
+		}
+		return "aac"
+	}
+	return c.Type
+}
+
+func (c Codec) mp3() bool {
+	return c.ObjectType == 0x69 || c.ObjectType == 0x6B || c.ObjectType == 0x40 && c.Profile == 34
+}
+
+// String returns the codec string of the entry.
+func (c Codec) String() string {
+	if c.raw != "" {
+		return c.raw
+	}
+	var s string
+	switch c.Type {
+	case "avc1", "avc3":
+		s = fmt.Sprintf("%s.%02X%02X%02X", c.Type, c.Profile, c.Constraints, c.Level)
+	case "hvc1", "hev1":
+		compatibility := c.Compatibility
+		if compatibility == 0 {
+			compatibility = 1 << uint(c.Profile)
+		}
+		s = fmt.Sprintf("%s.%d.%X.%s%d", c.Type, c.Profile, compatibility, c.Tier, c.Level)
+	case "av01":
+		s = fmt.Sprintf("%s.%d.%02d%s.%02d", c.Type, c.Profile, c.Level, c.Tier, c.BitDepth)
+	case "vp09":
+		s = fmt.Sprintf("%s.%02d.%02d.%02d", c.Type, c.Profile, c.Level, c.BitDepth)
+	case "dvh1", "dvhe", "dva1", "dvav", "dav1":
+		s = fmt.Sprintf("%s.%02d.%02d", c.Type, c.Profile, c.Level)
+	case "mp4a":
+		s = fmt.Sprintf("%s.%X", c.Type, c.ObjectType)
+		if c.Profile != 0 {
+			s += "." + strconv.Itoa(c.Profile)
+		}
+	default:
+		s = c.Type
+	}
+	if c.Extra != "" {
+		s += "." + c.Extra
+	}
+	return s
+}
+
+// Description returns the human-readable description of the codec.
+func (c Codec) Description() string {
+	switch c.Family() {
+	case "avc":
+		name := avcProfiles[c.Profile]
+		if name == "" {
+			name = "Profile " + strconv.Itoa(c.Profile)
+		} else if c.Profile == 66 && c.Constraints&0x40 != 0 {
+			name = "Constrained " + name
+		}
+		return fmt.Sprintf("H.264/AVC %s Level %.1f", name, float64(c.Level)/10)
+	case "hevc":
+		name := hevcProfiles[c.Profile]
+		if name == "" {
+			name = "Profile " + strconv.Itoa(c.Profile)
+		}
+		tier := "Main"
+		if c.Tier == "H" {
+			tier = "High"
+		}
+		return fmt.Sprintf("H.265/HEVC %s %s Tier Level %.1f", name, tier, float64(c.Level)/30)
+	case "av1":
+		name := av1Profiles[c.Profile]
+		if name == "" {
+			name = "Profile " + strconv.Itoa(c.Profile)
+		}
+		tier := "Main"
+		if c.Tier == "H" {
+			tier = "High"
+		}
+		return fmt.Sprintf("AV1 %s Level %d.%d %s Tier %d-bit", name, 2+c.Level>>2, c.Level&3, tier, c.BitDepth)
+	case "vp9":
+		return fmt.Sprintf("VP9 Profile %d Level %.1f %d-bit", c.Profile, float64(c.Level)/10, c.BitDepth)
+	case "dolby-vision":
+		return fmt.Sprintf("Dolby Vision Profile %d Level %d", c.Profile, c.Level)
+	case "aac":
+		if name := aacObjectTypes[c.Profile]; name != "" {
+			return name
+		}
+		return fmt.Sprintf("MPEG-4 Audio Object Type %d", c.Profile)
+	case "mp3":
+		return "MP3"
+	case "ac-3":
+		return "Dolby Digital (AC-3)"
+	case "ec-3":
+		return "Dolby Digital Plus (E-AC-3)"
+	case "ac-4":
+		return "Dolby AC-4"
+	case "opus", "Opus":
+		return "Opus"
+	case "fLaC":
+		return "FLAC"
+	case "alac":
+		return "Apple Lossless"
+	case "stpp":
+		return "TTML subtitles"
+	case "wvtt":
+		return "WebVTT subtitles"
+	case "tx3g":
+		return "3GPP timed text"
+	}
+	return c.String()
+}
+
+var (
+	avcProfiles = map[int]string{
+		66:  "Baseline",
+		77:  "Main",
+		88:  "Extended",
+		100: "High",
+		110: "High 10",
+		122: "High 4:2:2",
+		244: "High 4:4:4 Predictive",
+	}
+	hevcProfiles = map[int]string{
+		1: "Main",
+		2: "Main 10",
+		3: "Main Still Picture",
+		4: "Range Extensions",
+	}
+	av1Profiles = map[int]string{
+		0: "Main",
+		1: "High",
+		2: "Professional",
+	}
+	aacObjectTypes = map[int]string{
+		1:  "AAC Main",
+		2:  "AAC-LC",
+		5:  "HE-AAC",
+		29: "HE-AACv2",
+	}
+)
+
+// ParseCodecs parses the value of CODECS attribute. In strict mode
+// malformed entries and entries of unknown types are reported as
+// errors, otherwise they are kept as is.
+func ParseCodecs(codecs string, strict bool) ([]Codec, error) {
+	var out []Codec
+	for _, entry := range strings.Split(codecs, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			if strict {
+				return nil, fmt.Errorf("empty codec in %q", codecs)
+			}
+			continue
+		}
+		c, err := ParseCodec(entry)
+		if err != nil {
+			if strict {
+				return nil, err
+			}
+			c = Codec{Type: strings.SplitN(entry, ".", 2)[0], raw: entry}
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// ParseCodec parses the single codec string.
+func ParseCodec(entry string) (Codec, error) {
+	parts := strings.Split(entry, ".")
+	c := Codec{Type: parts[0]}
+	params := parts[1:]
+	fail := func(reason string) (Codec, error) {
+		return Codec{}, fmt.Errorf("invalid codec %q: %s", entry, reason)
+	}
+	var err error
+	switch c.Type {
+	case "avc1", "avc3":
+		if len(params) != 1 || len(params[0]) != 6 {
+			return fail("expected profile, constraints and level as 6 hex digits")
+		}
+		var v uint64
+		if v, err = strconv.ParseUint(params[0], 16, 32); err != nil {
+			return fail("expected profile, constraints and level as 6 hex digits")
+		}
+		c.Profile, c.Constraints, c.Level = int(v>>16), uint8(v>>8), int(v&0xFF)
+	case "hvc1", "hev1":
+		if len(params) < 3 || len(params) > 9 {
+			return fail("expected profile, compatibility flags, tier and level")
+		}
+		if c.Profile, err = strconv.Atoi(params[0]); err != nil || c.Profile < 0 || c.Profile > 31 {
+			return fail("invalid profile")
+		}
+		var v uint64
+		if v, err = strconv.ParseUint(params[1], 16, 32); err != nil {
+			return fail("invalid compatibility flags")
+		}
+		c.Compatibility = uint32(v)
+		if tl := params[2]; len(tl) < 2 || tl[0] != 'L' && tl[0] != 'H' {
+			return fail("expected tier L or H")
+		}
+		c.Tier = params[2][:1]
+		if c.Level, err = strconv.Atoi(params[2][1:]); err != nil || c.Level <= 0 {
+			return fail("invalid level")
+		}
+		for _, b := range params[3:] {
+			if _, err = strconv.ParseUint(b, 16, 8); err != nil {
+				return fail("invalid constraint flags")
+			}
+		}
+		c.Extra = strings.Join(params[3:], ".")
+	case "av01":
+		if len(params) < 3 || len(params[0]) != 1 || len(params[1]) != 3 || len(params[2]) != 2 {
+			return fail("expected profile, level, tier and bit depth")
+		}
+		if c.Profile, err = strconv.Atoi(params[0]); err != nil || c.Profile > 2 {
+			return fail("invalid profile")
+		}
+		if c.Level, err = strconv.Atoi(params[1][:2]); err != nil || c.Level > 31 {
+			return fail("invalid level")
+		}
+		if c.Tier = params[1][2:]; c.Tier != "M" && c.Tier != "H" {
+			return fail("expected tier M or H")
+		}
+		if c.BitDepth, err = strconv.Atoi(params[2]); err != nil || c.BitDepth != 8 && c.BitDepth != 10 && c.BitDepth != 12 {
+			return fail("invalid bit depth")
+		}
+		c.Extra = strings.Join(params[3:], ".")
+	case "vp09":
+		if len(params) < 3 || len(params[0]) != 2 || len(params[1]) != 2 || len(params[2]) != 2 {
+			return fail("expected profile, level and bit depth as 2 digits")
+		}
+		if c.Profile, err = strconv.Atoi(params[0]); err != nil || c.Profile > 3 {
+			return fail("invalid profile")
+		}
+		if c.Level, err = strconv.Atoi(params[1]); err != nil {
+			return fail("invalid level")
+		}
+		if c.BitDepth, err = strconv.Atoi(params[2]); err != nil || c.BitDepth != 8 && c.BitDepth != 10 && c.BitDepth != 12 {
+			return fail("invalid bit depth")
+		}
+		c.Extra = strings.Join(params[3:], ".")
+	case "dvh1", "dvhe", "dva1", "dvav", "dav1":
+		if len(params) != 2 || len(params[0]) != 2 || len(params[1]) != 2 {
+			return fail("expected profile and level as 2 digits")
+		}
+		if c.Profile, err = strconv.Atoi(params[0]); err != nil {
+			return fail("invalid profile")
+		}
+		if c.Level, err = strconv.Atoi(params[1]); err != nil || c.Level == 0 {
+			return fail("invalid level")
+		}
+	case "mp4a":
+		if len(params) < 1 || len(params) > 2 {
+			return fail("expected object type indication and audio object type")
+		}
+		var v uint64
+		if v, err = strconv.ParseUint(params[0], 16, 8); err != nil {
+			return fail("invalid object type indication")
+		}
+		c.ObjectType = int(v)
+		if len(params) == 2 {
+			if c.Profile, err = strconv.Atoi(params[1]); err != nil || c.Profile <= 0 {
+				return fail("invalid audio object type")
+			}
+		} else if c.ObjectType == 0x40 {
+			return fail("audio object type is required for MPEG-4 audio")
+		}
+	case "ac-3", "ec-3", "opus", "Opus", "fLaC", "alac", "wvtt", "tx3g":
+		if len(params) > 0 {
+			return fail("unexpected parameters")
+		}
+	case "ac-4", "stpp":
+		c.Extra = strings.Join(params, ".")
+	default:
+		return fail("unknown codec type")
+	}
+	return c, nil
+}
+
+// FormatCodecs returns the value of CODECS attribute for the codecs.
+func FormatCodecs(codecs []Codec) string {
+	entries := make([]string, len(codecs))
+	for i, c := range codecs {
+		entries[i] = c.String()
+	}
+	return strings.Join(entries, ",")
+}
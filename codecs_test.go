@@ -0,0 +1,101 @@
+/*
+ Codec strings tests.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCodecs(t *testing.T) {
+	tests := []struct {
+		codec       string
+		kind        MediaKind
+		family      string
+		description string
+	}{
+		{"avc1.64002A", MediaVideo, "avc", "H.264/AVC High Level 4.2"},
+		{"avc3.42C01E", MediaVideo, "avc", "H.264/AVC Constrained Baseline Level 3.0"},
+		{"hvc1.2.4.L123.B0", MediaVideo, "hevc", "H.265/HEVC Main 10 Main Tier Level 4.1"},
+		{"hev1.1.6.H150.90", MediaVideo, "hevc", "H.265/HEVC Main High Tier Level 5.0"},
+		{"av01.0.13M.10", MediaVideo, "av1", "AV1 Main Level 5.1 Main Tier 10-bit"},
+		{"av01.0.04M.10.0.112.09.16.09.0", MediaVideo, "av1", "AV1 Main Level 3.0 Main Tier 10-bit"},
+		{"vp09.00.31.08", MediaVideo, "vp9", "VP9 Profile 0 Level 3.1 8-bit"},
+		{"dvh1.05.06", MediaVideo, "dolby-vision", "Dolby Vision Profile 5 Level 6"},
+		{"mp4a.40.2", MediaAudio, "aac", "AAC-LC"},
+		{"mp4a.40.29", MediaAudio, "aac", "HE-AACv2"},
+		{"mp4a.6B", MediaAudio, "mp3", "MP3"},
+		{"ac-3", MediaAudio, "ac-3", "Dolby Digital (AC-3)"},
+		{"ec-3", MediaAudio, "ec-3", "Dolby Digital Plus (E-AC-3)"},
+		{"stpp.ttml.im1t", MediaText, "stpp", "TTML subtitles"},
+		{"wvtt", MediaText, "wvtt", "WebVTT subtitles"},
+	}
+	for _, test := range tests {
+		c, err := ParseCodec(test.codec)
+		if err != nil {
+			t.Errorf("%s: %s", test.codec, err)
+			continue
+		}
+		if c.Kind() != test.kind || c.Family() != test.family {
+			t.Errorf("%s: expected %s %s, got %s %s", test.codec, test.kind, test.family, c.Kind(), c.Family())
+		}
+		if c.Description() != test.description {
+			t.Errorf("%s: expected description %q, got %q", test.codec, test.description, c.Description())
+		}
+		if c.String() != test.codec {
+			t.Errorf("%s: formatted as %s", test.codec, c.String())
+		}
+	}
+}
+
+func TestParseCodecsStrict(t *testing.T) {
+	for _, codecs := range []string{
+		"avc1",
+		"avc1.64002",
+		"avc1.64002A,",
+		"hvc1.2.4.X123",
+		"av01.0.13X.10",
+		"vp09.00.31.09",
+		"dvh1.5.6",
+		"mp4a.40",
+		"ac-3.1",
+		"xyz1.2",
+	} {
+		if _, err := ParseCodecs(codecs, true); err == nil {
+			t.Errorf("Expected error for %q", codecs)
+		}
+	}
+	codecs, err := ParseCodecs("avc1, mp4a.40.2", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codecs) != 2 || codecs[0].Type != "avc1" || codecs[0].String() != "avc1" || codecs[1].Profile != 2 {
+		t.Errorf("Unexpected codecs: %+v", codecs)
+	}
+	if codecs, err = ParseCodecs("avc1.640028,mp4a.40.2", true); err != nil || len(codecs) != 2 {
+		t.Errorf("Unexpected result: %+v, %v", codecs, err)
+	}
+}
+
+func TestMasterAppendCodecList(t *testing.T) {
+	m := NewMasterPlaylist()
+	p, _ := NewMediaPlaylist(1, 1)
+	m.Append("hd.m3u8", p, VariantParams{Bandwidth: 3000000, CodecList: []Codec{
+		{Type: "avc1", Profile: 100, Level: 40},
+		{Type: "hvc1", Profile: 2, Tier: "L", Level: 120},
+		{Type: "mp4a", ObjectType: 0x40, Profile: 2},
+		{Type: "ec-3"},
+	}})
+	m.Append("sd.m3u8", p, VariantParams{Bandwidth: 1000000, Codecs: "avc1.4d401e", CodecList: []Codec{{Type: "avc1"}}})
+	expected := `CODECS="avc1.640028,hvc1.2.4.L120,mp4a.40.2,ec-3"`
+	if out := m.String(); !strings.Contains(out, expected) || !strings.Contains(out, `CODECS="avc1.4d401e"`) {
+		t.Errorf("Master playlist did not contain: %s\n%s", expected, out)
+	}
+}
@@ -0,0 +1,115 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines conversion of SCTE-35 cues between syntaxes of the
+ cue tags.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// ConvertSCTE35 rewrites the cues of the playlist from one syntax to
+// another. Cues in other syntaxes are left as is.
+//
+// Attributes missed in the source syntax are derived from the break:
+// segments inside of the break get their own cues with the elapsed
+// time if the target syntax has them, otherwise such cues are removed.
+// Cues are encoded in hex for SCTE35_DATERANGE and in base64 for other
+// syntaxes, date ranges get IDs from the sequence numbers of the first
+// segments of breaks.
+func (p *MediaPlaylist) ConvertSCTE35(from, to SCTE35Syntax) {
+	var (
+		out     *SCTE // the cue started the current break
+		elapsed float64
+	)
+	p.ForEach(func(seg *MediaSegment) bool {
+		switch {
+		case seg.SCTE != nil && seg.SCTE.Syntax == from:
+			scte := *seg.SCTE
+			scte.Syntax = to
+			scte.Cue = convertCueEncoding(scte.Cue, to)
+			switch scte.CueType {
+			case SCTE35Cue_Start:
+				if scte.ID == "" && to == SCTE35_DATERANGE {
+					scte.ID = fmt.Sprintf("splice-%d", seg.SeqId)
+				}
+				out, elapsed = &scte, 0
+			case SCTE35Cue_Mid:
+				if out == nil {
+					// the break started before the first segment
+					out, elapsed = &scte, scte.Elapsed
+				}
+				if scte.Cue == "" {
+					scte.Cue = out.Cue
+				}
+				if scte.Time == 0 {
+					scte.Time = out.Time
+				}
+				if scte.Elapsed == 0 {
+					scte.Elapsed = elapsed
+				}
+			case SCTE35Cue_End:
+				if out != nil {
+					if scte.ID == "" {
+						scte.ID = out.ID
+					}
+					if scte.Time == 0 && to == SCTE35_DATERANGE {
+						scte.Time = elapsed
+					}
+				}
+				out = nil
+			}
+			seg.SCTE = &scte
+			if scte.CueType == SCTE35Cue_Mid && !hasMidCues(to) {
+				seg.SCTE = nil
+			}
+		case seg.SCTE == nil && out != nil && hasMidCues(to):
+			seg.SCTE = &SCTE{Syntax: to, CueType: SCTE35Cue_Mid, Cue: out.Cue, Time: out.Time, Elapsed: elapsed}
+		}
+		if out != nil {
+			elapsed += seg.Duration
+		}
+		return true
+	})
+	p.buf.Reset()
+}
+
+// hasMidCues reports whether the syntax has tags for the segments
+// inside of breaks.
+func hasMidCues(syntax SCTE35Syntax) bool {
+	switch syntax {
+	case SCTE35_OATCLS, SCTE35_ELEMENTAL, SCTE35_ANVATO:
+		return true
+	}
+	return false
+}
+
+// convertCueEncoding converts the cue to hex with 0x prefix for
+// EXT-X-DATERANGE and to base64 for other syntaxes. Cues which can't
+// be decoded are returned as is.
+func convertCueEncoding(cue string, to SCTE35Syntax) string {
+	isHex := strings.HasPrefix(cue, "0x") || strings.HasPrefix(cue, "0X")
+	switch {
+	case cue == "":
+	case to == SCTE35_DATERANGE && !isHex:
+		if data, err := base64.StdEncoding.DecodeString(cue); err == nil {
+			return "0x" + strings.ToUpper(hex.EncodeToString(data))
+		}
+	case to != SCTE35_DATERANGE && isHex:
+		if data, err := hex.DecodeString(cue[2:]); err == nil {
+			return base64.StdEncoding.EncodeToString(data)
+		}
+	}
+	return cue
+}
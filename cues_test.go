@@ -0,0 +1,87 @@
+/*
+ SCTE-35 cue conversion tests.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConvertSCTE35ToDateRange(t *testing.T) {
+	f, err := os.Open("sample-playlists/media-playlist-with-oatcls-scte35.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewMediaPlaylist(3, 3)
+	if err != nil {
+		t.Fatalf("Create media playlist failed: %s", err)
+	}
+	if err = p.DecodeFrom(bufio.NewReader(f), true); err != nil {
+		t.Fatal(err)
+	}
+	// START-DATE of date ranges is derived from PROGRAM-DATE-TIME
+	p.Segments[0].ProgramDateTime = time.Date(2014, 3, 5, 11, 15, 0, 0, time.UTC)
+	p.ConvertSCTE35(SCTE35_OATCLS, SCTE35_DATERANGE)
+	if p.Segments[1].SCTE != nil {
+		t.Errorf("Cue inside of the break must be removed: %+v", p.Segments[1].SCTE)
+	}
+	expected := `#EXT-X-DATERANGE:ID="splice-0",START-DATE="2014-03-05T11:15:00Z",PLANNED-DURATION=15,SCTE35-OUT=0xFC302500000000000000FFF01405000000017FEFFE00D80D92FE00149970000101010000E7150B2C
+#EXT-X-PROGRAM-DATE-TIME:2014-03-05T11:15:00Z
+#EXTINF:8.844,
+media0.ts
+#EXTINF:6.156,
+media1.ts
+#EXT-X-DATERANGE:ID="splice-0",START-DATE="2014-03-05T11:15:00Z",DURATION=15,SCTE35-IN=
+#EXTINF:3.844,
+media2.ts
+`
+	if !strings.HasSuffix(p.String(), expected) {
+		t.Errorf("Unexpected converted playlist:\n%s\nexpected suffix:\n%s", p, expected)
+	}
+}
+
+func TestConvertSCTE35FromDateRange(t *testing.T) {
+	f, err := os.Open("sample-playlists/media-playlist-with-daterange-scte35.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewMediaPlaylist(4, 4)
+	if err != nil {
+		t.Fatalf("Create media playlist failed: %s", err)
+	}
+	if err = p.DecodeFrom(bufio.NewReader(f), true); err != nil {
+		t.Fatal(err)
+	}
+	p.ConvertSCTE35(SCTE35_DATERANGE, SCTE35_OATCLS)
+	cue := "/DAlAAAAAAAAAP/wFAUAAAABf+/+ANgNkv4AFJlwAAEBAQAA5xULLA=="
+	expected := `#EXT-OATCLS-SCTE35:` + cue + `
+#EXT-X-CUE-OUT:15
+#EXTINF:8.844,
+media1.ts
+#EXT-X-CUE-OUT-CONT:ElapsedTime=8.844,Duration=15,SCTE35=` + cue + `
+#EXTINF:6.156,
+media2.ts
+#EXT-X-CUE-IN
+#EXTINF:3.844,
+media3.ts
+`
+	if !strings.HasSuffix(p.String(), expected) {
+		t.Errorf("Unexpected converted playlist:\n%s\nexpected suffix:\n%s", p, expected)
+	}
+
+	// cues in other syntaxes are not changed
+	p.ConvertSCTE35(SCTE35_ADOBE, SCTE35_ELEMENTAL)
+	if p.Segments[1].SCTE.Syntax != SCTE35_OATCLS {
+		t.Errorf("Unexpected syntax: %d", p.Segments[1].SCTE.Syntax)
+	}
+}
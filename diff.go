@@ -0,0 +1,435 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines functions related to comparison of playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DiffKind is the type of the change found between two playlists.
+type DiffKind uint
+
+const (
+	DiffAdded   DiffKind = iota + 1 // DiffAdded marks elements present only in the new playlist
+	DiffRemoved                     // DiffRemoved marks elements present only in the old playlist
+	DiffChanged                     // DiffChanged marks elements present in both playlists but with other values
+)
+
+func (k DiffKind) String() string {
+	switch k {
+	case DiffAdded:
+		return "+"
+	case DiffRemoved:
+		return "-"
+	case DiffChanged:
+		return "~"
+	}
+	return "?"
+}
+
+// FieldChange describes the changed value of a playlist attribute.
+// Values are kept in their text form.
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// SegmentChange describes the media segment added, removed or changed
+// between two media playlists. Segments matched by their SeqId.
+type SegmentChange struct {
+	Kind   DiffKind
+	SeqId  uint64
+	Old    *MediaSegment // nil for added segments
+	New    *MediaSegment // nil for removed segments
+	Fields []FieldChange // changed attributes for DiffChanged
+}
+
+// VariantChange describes the variant added, removed or changed
+// between two master playlists. Variants matched by their URI.
+type VariantChange struct {
+	Kind   DiffKind
+	URI    string
+	Old    *Variant
+	New    *Variant
+	Fields []FieldChange
+}
+
+// AlternativeChange describes the alternative rendition (EXT-X-MEDIA)
+// added, removed or changed between two master playlists. Renditions
+// matched by their URI or by TYPE, GROUP-ID and NAME for renditions
+// without URI.
+type AlternativeChange struct {
+	Kind   DiffKind
+	Key    string
+	Old    *Alternative
+	New    *Alternative
+	Fields []FieldChange
+}
+
+// PlaylistDiff is the structured result of playlists comparison.
+// Header keeps changes of playlist level attributes. Segments filled
+// only for media playlists, Variants and Alternatives only for master
+// playlists.
+type PlaylistDiff struct {
+	Header       []FieldChange
+	Segments     []SegmentChange
+	Variants     []VariantChange
+	Alternatives []AlternativeChange
+}
+
+// Empty returns true if compared playlists are equal.
+func (d *PlaylistDiff) Empty() bool {
+	return len(d.Header) == 0 && len(d.Segments) == 0 && len(d.Variants) == 0 && len(d.Alternatives) == 0
+}
+
+// String renders the diff as text with one change per line. Lines
+// are prefixed by "+" for added, "-" for removed and "~" for changed
+// elements.
+func (d *PlaylistDiff) String() string {
+	var buf bytes.Buffer
+	writeField := func(prefix string, f FieldChange) {
+		buf.WriteString(prefix)
+		buf.WriteString(f.Field)
+		buf.WriteString(": ")
+		buf.WriteString(strconv.Quote(f.Old))
+		buf.WriteString(" -> ")
+		buf.WriteString(strconv.Quote(f.New))
+		buf.WriteRune('\n')
+	}
+	writeFields := func(fields []FieldChange) {
+		for _, f := range fields {
+			writeField("    ", f)
+		}
+	}
+	for _, f := range d.Header {
+		writeField("~ ", f)
+	}
+	for _, s := range d.Segments {
+		buf.WriteString(s.Kind.String())
+		buf.WriteString(" segment ")
+		buf.WriteString(strconv.FormatUint(s.SeqId, 10))
+		switch s.Kind {
+		case DiffAdded:
+			buf.WriteString(": ")
+			buf.WriteString(s.New.URI)
+		case DiffRemoved:
+			buf.WriteString(": ")
+			buf.WriteString(s.Old.URI)
+		}
+		buf.WriteRune('\n')
+		writeFields(s.Fields)
+	}
+	for _, v := range d.Variants {
+		buf.WriteString(v.Kind.String())
+		buf.WriteString(" variant ")
+		buf.WriteString(v.URI)
+		buf.WriteRune('\n')
+		writeFields(v.Fields)
+	}
+	for _, a := range d.Alternatives {
+		buf.WriteString(a.Kind.String())
+		buf.WriteString(" rendition ")
+		buf.WriteString(a.Key)
+		buf.WriteRune('\n')
+		writeFields(a.Fields)
+	}
+	return buf.String()
+}
+
+// Diff compares two media playlists or two master playlists and
+// returns the structured list of their differences. Playlist `a` is
+// treated as the old one and `b` as the new one.
+func Diff(a, b Playlist) (*PlaylistDiff, error) {
+	switch old := a.(type) {
+	case *MediaPlaylist:
+		if cur, ok := b.(*MediaPlaylist); ok {
+			return diffMedia(old, cur), nil
+		}
+	case *MasterPlaylist:
+		if cur, ok := b.(*MasterPlaylist); ok {
+			return diffMaster(old, cur), nil
+		}
+	}
+	return nil, errors.New("playlists must be both master or both media playlists")
+}
+
+// Equal reports whether two media playlists have the same attributes
+// and the same segments. Layout of the internal segments buffer and
+// the size of the sliding window are not compared.
+func (p *MediaPlaylist) Equal(other *MediaPlaylist) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	return diffMedia(p, other).Empty()
+}
+
+// Equal reports whether two master playlists have the same attributes,
+// variants and alternative renditions. Linked chunklists are not
+// compared.
+func (p *MasterPlaylist) Equal(other *MasterPlaylist) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	return diffMaster(p, other).Empty()
+}
+
+func diffMedia(a, b *MediaPlaylist) *PlaylistDiff {
+	d := new(PlaylistDiff)
+	d.Header = diffField(d.Header, "Version", a.ver, b.ver)
+	d.Header = diffField(d.Header, "TargetDuration", a.TargetDuration, b.TargetDuration)
+	d.Header = diffField(d.Header, "SeqNo", a.SeqNo, b.SeqNo)
+	d.Header = diffField(d.Header, "DiscontinuitySeq", a.DiscontinuitySeq, b.DiscontinuitySeq)
+	d.Header = diffField(d.Header, "MediaType", a.MediaType, b.MediaType)
+	d.Header = diffField(d.Header, "Closed", a.Closed, b.Closed)
+	d.Header = diffField(d.Header, "Iframe", a.Iframe, b.Iframe)
+	d.Header = diffField(d.Header, "StartTime", a.StartTime, b.StartTime)
+	d.Header = diffField(d.Header, "StartTimePrecise", a.StartTimePrecise, b.StartTimePrecise)
+	d.Header = diffField(d.Header, "Args", a.Args, b.Args)
+	d.Header = diffField(d.Header, "Key", a.Key, b.Key)
+	d.Header = diffField(d.Header, "Keys", a.Keys, b.Keys)
+	d.Header = diffField(d.Header, "Map", a.Map, b.Map)
+	d.Header = diffField(d.Header, "WV", a.WV, b.WV)
+	d.Header = diffField(d.Header, "Custom", a.Custom, b.Custom)
+
+	old := make(map[uint64]*MediaSegment)
+	for _, seg := range a.OrderedSegments() {
+		old[seg.SeqId] = seg
+	}
+	cur := make(map[uint64]bool)
+	for _, seg := range b.OrderedSegments() {
+		cur[seg.SeqId] = true
+		prev, ok := old[seg.SeqId]
+		if !ok {
+			d.Segments = append(d.Segments, SegmentChange{Kind: DiffAdded, SeqId: seg.SeqId, New: seg})
+			continue
+		}
+		if fields := diffSegment(prev, seg); len(fields) > 0 {
+			d.Segments = append(d.Segments, SegmentChange{Kind: DiffChanged, SeqId: seg.SeqId, Old: prev, New: seg, Fields: fields})
+		}
+	}
+	for _, seg := range a.OrderedSegments() {
+		if !cur[seg.SeqId] {
+			d.Segments = append(d.Segments, SegmentChange{Kind: DiffRemoved, SeqId: seg.SeqId, Old: seg})
+		}
+	}
+	sort.SliceStable(d.Segments, func(i, j int) bool {
+		return d.Segments[i].SeqId < d.Segments[j].SeqId
+	})
+	return d
+}
+
+func diffSegment(a, b *MediaSegment) []FieldChange {
+	var fields []FieldChange
+	fields = diffField(fields, "URI", a.URI, b.URI)
+	fields = diffField(fields, "Title", a.Title, b.Title)
+	fields = diffField(fields, "Duration", a.Duration, b.Duration)
+	fields = diffField(fields, "Limit", a.Limit, b.Limit)
+	fields = diffField(fields, "Offset", a.Offset, b.Offset)
+	fields = diffField(fields, "Key", a.Key, b.Key)
+	fields = diffField(fields, "Keys", a.Keys, b.Keys)
+	fields = diffField(fields, "Map", a.Map, b.Map)
+	fields = diffField(fields, "Discontinuity", a.Discontinuity, b.Discontinuity)
+	fields = diffField(fields, "SCTE", a.SCTE, b.SCTE)
+	fields = diffField(fields, "ProgramDateTime", a.ProgramDateTime, b.ProgramDateTime)
+	fields = diffField(fields, "Custom", a.Custom, b.Custom)
+	return fields
+}
+
+func diffMaster(a, b *MasterPlaylist) *PlaylistDiff {
+	d := new(PlaylistDiff)
+	d.Header = diffField(d.Header, "Version", a.ver, b.ver)
+	d.Header = diffField(d.Header, "IndependentSegments", a.independentSegments, b.independentSegments)
+	d.Header = diffField(d.Header, "Args", a.Args, b.Args)
+	d.Header = diffField(d.Header, "CypherVersion", a.CypherVersion, b.CypherVersion)
+	d.Header = diffField(d.Header, "Custom", a.Custom, b.Custom)
+
+	// variants with the same URI (for example the same I-frame
+	// playlist for several variants) are matched in order
+	old := make(map[string][]*Variant)
+	for _, v := range a.Variants {
+		if v != nil {
+			old[v.URI] = append(old[v.URI], v)
+		}
+	}
+	for _, v := range b.Variants {
+		if v == nil {
+			continue
+		}
+		if len(old[v.URI]) == 0 {
+			d.Variants = append(d.Variants, VariantChange{Kind: DiffAdded, URI: v.URI, New: v})
+			continue
+		}
+		prev := old[v.URI][0]
+		old[v.URI] = old[v.URI][1:]
+		if fields := diffVariant(prev, v); len(fields) > 0 {
+			d.Variants = append(d.Variants, VariantChange{Kind: DiffChanged, URI: v.URI, Old: prev, New: v, Fields: fields})
+		}
+	}
+	for _, v := range a.Variants {
+		if v == nil {
+			continue
+		}
+		for _, rest := range old[v.URI] {
+			if rest == v {
+				d.Variants = append(d.Variants, VariantChange{Kind: DiffRemoved, URI: v.URI, Old: v})
+			}
+		}
+	}
+
+	oldAlts, oldKeys := alternativesOf(a)
+	curAlts, curKeys := alternativesOf(b)
+	for _, key := range curKeys {
+		prev, ok := oldAlts[key]
+		if !ok {
+			d.Alternatives = append(d.Alternatives, AlternativeChange{Kind: DiffAdded, Key: key, New: curAlts[key]})
+			continue
+		}
+		if fields := diffAlternative(prev, curAlts[key]); len(fields) > 0 {
+			d.Alternatives = append(d.Alternatives, AlternativeChange{Kind: DiffChanged, Key: key, Old: prev, New: curAlts[key], Fields: fields})
+		}
+	}
+	for _, key := range oldKeys {
+		if _, ok := curAlts[key]; !ok {
+			d.Alternatives = append(d.Alternatives, AlternativeChange{Kind: DiffRemoved, Key: key, Old: oldAlts[key]})
+		}
+	}
+	return d
+}
+
+func diffVariant(a, b *Variant) []FieldChange {
+	var fields []FieldChange
+	fields = diffField(fields, "Iframe", a.Iframe, b.Iframe)
+	fields = diffField(fields, "ProgramId", a.ProgramId, b.ProgramId)
+	fields = diffField(fields, "Bandwidth", a.Bandwidth, b.Bandwidth)
+	fields = diffField(fields, "AverageBandwidth", a.AverageBandwidth, b.AverageBandwidth)
+	fields = diffField(fields, "Codecs", a.Codecs, b.Codecs)
+	fields = diffField(fields, "Resolution", a.Resolution, b.Resolution)
+	fields = diffField(fields, "Audio", a.Audio, b.Audio)
+	fields = diffField(fields, "Video", a.Video, b.Video)
+	fields = diffField(fields, "Subtitles", a.Subtitles, b.Subtitles)
+	fields = diffField(fields, "Captions", a.Captions, b.Captions)
+	fields = diffField(fields, "Name", a.Name, b.Name)
+	fields = diffField(fields, "VideoRange", a.VideoRange, b.VideoRange)
+	fields = diffField(fields, "HDCPLevel", a.HDCPLevel, b.HDCPLevel)
+	fields = diffField(fields, "FrameRate", a.FrameRate, b.FrameRate)
+	return fields
+}
+
+func diffAlternative(a, b *Alternative) []FieldChange {
+	var fields []FieldChange
+	fields = diffField(fields, "Type", a.Type, b.Type)
+	fields = diffField(fields, "URI", a.URI, b.URI)
+	fields = diffField(fields, "GroupId", a.GroupId, b.GroupId)
+	fields = diffField(fields, "Language", a.Language, b.Language)
+	fields = diffField(fields, "Name", a.Name, b.Name)
+	fields = diffField(fields, "Default", a.Default, b.Default)
+	fields = diffField(fields, "Autoselect", a.Autoselect, b.Autoselect)
+	fields = diffField(fields, "Forced", a.Forced, b.Forced)
+	fields = diffField(fields, "InstreamId", a.InstreamId, b.InstreamId)
+	fields = diffField(fields, "Characteristics", a.Characteristics, b.Characteristics)
+	fields = diffField(fields, "Channels", a.Channels, b.Channels)
+	return fields
+}
+
+// alternativesOf collects unique renditions of all variants of the
+// master playlist keyed by URI. It returns keys in order of their
+// appearance.
+func alternativesOf(p *MasterPlaylist) (map[string]*Alternative, []string) {
+	alts := make(map[string]*Alternative)
+	var keys []string
+	for _, v := range p.Variants {
+		if v == nil {
+			continue
+		}
+		for _, alt := range v.Alternatives {
+			if alt == nil {
+				continue
+			}
+			key := alt.URI
+			if key == "" {
+				key = strings.Join([]string{alt.Type, alt.GroupId, alt.Name}, "/")
+			}
+			if _, ok := alts[key]; !ok {
+				keys = append(keys, key)
+				alts[key] = alt
+			}
+		}
+	}
+	return alts, keys
+}
+
+// diffField appends the change to the list if text forms of the old
+// and the new values differ.
+func diffField(fields []FieldChange, name string, old, cur interface{}) []FieldChange {
+	o, c := diffValue(old), diffValue(cur)
+	if o != c {
+		fields = append(fields, FieldChange{Field: name, Old: o, New: c})
+	}
+	return fields
+}
+
+func diffValue(v interface{}) string {
+	switch v := v.(type) {
+	case *Key:
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprintf("METHOD=%s,URI=%s,IV=%s,KEYFORMAT=%s,KEYFORMATVERSIONS=%s", v.Method, v.URI, v.IV, v.Keyformat, v.Keyformatversions)
+	case []*Key:
+		keys := make([]string, len(v))
+		for i, key := range v {
+			keys[i] = diffValue(key)
+		}
+		return strings.Join(keys, "; ")
+	case *Map:
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprintf("URI=%s,BYTERANGE=%d@%d", v.URI, v.Limit, v.Offset)
+	case *SCTE:
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprintf("%+v", *v)
+	case *WV:
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprintf("%+v", *v)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.UTC().Format(DATETIME)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]CustomTag:
+		tags := make([]string, 0, len(v))
+		for name, tag := range v {
+			if tag == nil {
+				tags = append(tags, name)
+				continue
+			}
+			tags = append(tags, tag.String())
+		}
+		sort.Strings(tags)
+		return strings.Join(tags, "\n")
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,140 @@
+/*
+ Playlist comparison tests.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestEqualMediaPlaylist(t *testing.T) {
+	f, err := os.Open("sample-playlists/media-playlist-with-scte35.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, listType, err := DecodeFrom(bufio.NewReader(f), true)
+	if err != nil || listType != MEDIA {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	a := p.(*MediaPlaylist)
+	b := a.Clone()
+	if !a.Equal(b) {
+		t.Fatal("Copy of the playlist must be equal to the original")
+	}
+	for _, seg := range b.Segments {
+		if seg != nil && seg.SCTE != nil {
+			seg.SCTE.Cue = "changed"
+			break
+		}
+	}
+	if a.Equal(b) {
+		t.Error("Playlists with different SCTE cues must not be equal")
+	}
+}
+
+func TestDiffMediaPlaylist(t *testing.T) {
+	a, _ := NewMediaPlaylist(3, 10)
+	for i := 0; i < 4; i++ {
+		a.Slide(fmt.Sprintf("test%d.ts", i), 6.0, "")
+	}
+	b := a.Clone()
+	b.Slide("test4.ts", 6.0, "")
+	b.Slide("test5.ts", 12.0, "")
+	b.Segments[b.head].URI = "changed.ts"
+	b.DiscontinuitySeq = 1
+
+	d, err := Diff(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var added, removed, changed []uint64
+	for _, s := range d.Segments {
+		switch s.Kind {
+		case DiffAdded:
+			added = append(added, s.SeqId)
+		case DiffRemoved:
+			removed = append(removed, s.SeqId)
+		case DiffChanged:
+			changed = append(changed, s.SeqId)
+			if len(s.Fields) != 1 || s.Fields[0].Field != "URI" || s.Fields[0].New != "changed.ts" {
+				t.Errorf("Unexpected segment changes: %+v", s.Fields)
+			}
+		}
+	}
+	if fmt.Sprint(added) != "[4 5]" || fmt.Sprint(removed) != "[1 2]" || fmt.Sprint(changed) != "[3]" {
+		t.Errorf("Unexpected segments diff: added %v, removed %v, changed %v", added, removed, changed)
+	}
+	header := make(map[string]FieldChange)
+	for _, f := range d.Header {
+		header[f.Field] = f
+	}
+	if f := header["SeqNo"]; f.Old != "1" || f.New != "3" {
+		t.Errorf("Unexpected SeqNo change: %+v", f)
+	}
+	if f := header["TargetDuration"]; f.Old != "6" || f.New != "12" {
+		t.Errorf("Unexpected TargetDuration change: %+v", f)
+	}
+	if _, ok := header["DiscontinuitySeq"]; !ok || len(header) != 3 {
+		t.Errorf("Unexpected header changes: %+v", d.Header)
+	}
+	text := d.String()
+	for _, line := range []string{"~ SeqNo: \"1\" -> \"3\"\n", "+ segment 5: test5.ts\n", "- segment 1: test1.ts\n", "~ segment 3\n    URI: \"test3.ts\" -> \"changed.ts\"\n"} {
+		if !strings.Contains(text, line) {
+			t.Errorf("Diff text does not contain %q:\n%s", line, text)
+		}
+	}
+}
+
+func TestDiffMasterPlaylist(t *testing.T) {
+	f, err := os.Open("sample-playlists/master-with-alternatives.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewMasterPlaylist()
+	if err = a.DecodeFrom(bufio.NewReader(f), false); err != nil {
+		t.Fatal(err)
+	}
+	b := a.Clone()
+	if !a.Equal(b) {
+		t.Fatal("Copy of the playlist must be equal to the original")
+	}
+	b.Variants[0].Bandwidth = 1000000
+	b.Variants[1].Alternatives[1].Language = "en"
+	b.Variants = b.Variants[:3]
+	b.Append("new.m3u8", nil, VariantParams{Bandwidth: 100})
+
+	d, err := Diff(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Variants) != 3 {
+		t.Fatalf("Expected 3 variant changes, got: %+v", d.Variants)
+	}
+	if v := d.Variants[0]; v.Kind != DiffChanged || v.URI != "low/main/audio-video.m3u8" || v.Fields[0].Field != "Bandwidth" {
+		t.Errorf("Unexpected variant change: %+v", v)
+	}
+	if v := d.Variants[1]; v.Kind != DiffAdded || v.URI != "new.m3u8" {
+		t.Errorf("Unexpected variant change: %+v", v)
+	}
+	if v := d.Variants[2]; v.Kind != DiffRemoved || v.URI != "main/audio-only.m3u8" {
+		t.Errorf("Unexpected variant change: %+v", v)
+	}
+	if len(d.Alternatives) != 1 || d.Alternatives[0].Key != "mid/centerfield/audio-video.m3u8" || d.Alternatives[0].Fields[0].Field != "Language" {
+		t.Errorf("Unexpected rendition changes: %+v", d.Alternatives)
+	}
+
+	m, _ := NewMediaPlaylist(1, 1)
+	if _, err = Diff(a, m); err == nil {
+		t.Error("Diff of master and media playlists must fail")
+	}
+}
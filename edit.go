@@ -0,0 +1,325 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines functions related to editing of media playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"errors"
+)
+
+// ErrMapMismatch declares the error returned when segments without
+// EXT-X-MAP are joined after the segments with EXT-X-MAP.
+var ErrMapMismatch = errors.New("segments without EXT-X-MAP can't follow segments with EXT-X-MAP")
+
+// Clip returns a new closed (VOD) media playlist with the segments
+// which cover the time range from `start` to `end` (offsets in seconds
+// from the start of the oldest segment of the playlist). Segments are
+// never split: EXT-X-START with PRECISE=YES points to the exact start
+// offset inside of the first segment.
+//
+// The first segment of the clip carries EXT-X-KEY and EXT-X-MAP which
+// were in force for it in the original playlist and its wall-clock
+// time if it is known. Media sequence and discontinuity sequence are
+// adjusted to the numbers of the first segment. Segments are copied so
+// the clip may be changed independently of the original playlist.
+func (p *MediaPlaylist) Clip(start, end float64) (*MediaPlaylist, error) {
+	if start < 0 || end <= start {
+		return nil, errors.New("invalid clip range")
+	}
+	timeline := p.Timeline()
+	first, ok := timelineIndex(timeline, start)
+	if !ok {
+		return nil, ErrOutOfRange
+	}
+	last := first
+	for last+1 < len(timeline) && timeline[last+1].Start < end {
+		last++
+	}
+
+	clip, err := NewMediaPlaylist(0, uint(last-first+1))
+	if err != nil {
+		return nil, err
+	}
+	p.copyHeaderTo(clip)
+	clip.MediaType = VOD
+	clip.SeqNo = timeline[first].Segment.SeqId
+	clip.DiscontinuitySeq = p.DiscontinuitySeq
+	// removed segments take their EXT-X-DISCONTINUITY tags with them
+	for _, e := range timeline[:first] {
+		if e.Segment.Discontinuity {
+			clip.DiscontinuitySeq++
+		}
+	}
+	key, keys, xmap := p.Key, p.Keys, p.Map
+	for _, e := range timeline[:first+1] {
+		if e.Segment.Key != nil {
+			key, keys = e.Segment.Key, e.Segment.Keys
+		}
+		if e.Segment.Map != nil {
+			xmap = e.Segment.Map
+		}
+	}
+
+	c := newCloner()
+	for i, e := range timeline[first : last+1] {
+		seg := c.segment(e.Segment)
+		if i == 0 {
+			// the key and the map emitted before the first segment,
+			// default ones would hide map changes of next segments
+			seg.Key, seg.Keys = c.key(key), c.keyList(keys)
+			seg.Map = c.xmap(xmap)
+			if seg.ProgramDateTime.IsZero() {
+				seg.ProgramDateTime = e.ProgramDateTime
+			}
+		}
+		if err = clip.AppendSegment(seg); err != nil {
+			return nil, err
+		}
+	}
+	clip.StartTime = start - timeline[first].Start
+	clip.StartTimePrecise = clip.StartTime > 0
+	clip.Close()
+	return clip, nil
+}
+
+// copyHeaderTo copies playlist level attributes which are not related
+// to the sequence of segments.
+func (p *MediaPlaylist) copyHeaderTo(dst *MediaPlaylist) {
+	dst.ver = p.ver
+	dst.Args = p.Args
+	dst.Iframe = p.Iframe
+	dst.durationAsInt = p.durationAsInt
+	dst.targetRounding = p.targetRounding
+	dst.keyformat = p.keyformat
+	if p.WV != nil {
+		wv := *p.WV
+		dst.WV = &wv
+	}
+	dst.Custom = cloneCustom(p.Custom)
+	dst.BaseURL = cloneURL(p.BaseURL)
+	dst.customDecoders = append([]CustomDecoder(nil), p.customDecoders...)
+}
+
+// Concat joins media playlists into a new one. EXT-X-DISCONTINUITY is
+// inserted at the boundary of each joined playlist. EXT-X-KEY and
+// EXT-X-MAP are emitted before a segment only when the key or the map
+// in force for it differ from the previous segment. Media sequence
+// numbers continue from the first playlist, target duration is
+// recomputed from the joined segments. The result is closed if all the
+// joined playlists are closed. Segments are copied so the result may
+// be changed independently of the sources. HLS has no means to cancel
+// EXT-X-MAP so segments without the map can't follow the segments
+// with the map, such joins return ErrMapMismatch.
+func Concat(playlists ...*MediaPlaylist) (*MediaPlaylist, error) {
+	if len(playlists) == 0 {
+		return nil, errors.New("no playlists to join")
+	}
+	j, err := newJoiner(playlists[0], playlists...)
+	if err != nil {
+		return nil, err
+	}
+	closed := true
+	for _, src := range playlists {
+		if err = j.append(src, 0, src.Count()); err != nil {
+			return nil, err
+		}
+		closed = closed && src.Closed
+	}
+	return j.finish(closed), nil
+}
+
+// SpliceAt returns a new playlist with the segments of `insert` placed
+// before the segment with sequence number `seqId`. Boundaries of the
+// inserted playlist are marked with EXT-X-DISCONTINUITY, keys and maps
+// are re-emitted like for Concat. It returns ErrMapMismatch when only
+// one of the playlists has EXT-X-MAP.
+func (p *MediaPlaylist) SpliceAt(seqId uint64, insert *MediaPlaylist) (*MediaPlaylist, error) {
+	at := -1
+	for i, seg := range p.OrderedSegments() {
+		if seg.SeqId == seqId {
+			at = i
+			break
+		}
+	}
+	if at < 0 {
+		return nil, ErrOutOfRange
+	}
+	return p.splice(uint(at), insert)
+}
+
+// SpliceAtTime returns a new playlist with the segments of `insert`
+// placed at the first segment boundary at or after the media time
+// offset (in seconds from the start of the oldest segment). Segments
+// are never split. The offset equal to the playlist duration appends
+// `insert` to the end.
+func (p *MediaPlaylist) SpliceAtTime(offset float64, insert *MediaPlaylist) (*MediaPlaylist, error) {
+	timeline := p.Timeline()
+	if offset < 0 || offset > p.Duration() {
+		return nil, ErrOutOfRange
+	}
+	at := len(timeline)
+	for i, e := range timeline {
+		if e.Start >= offset {
+			at = i
+			break
+		}
+	}
+	return p.splice(uint(at), insert)
+}
+
+func (p *MediaPlaylist) splice(at uint, insert *MediaPlaylist) (*MediaPlaylist, error) {
+	j, err := newJoiner(p, p, insert)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range []struct {
+		src      *MediaPlaylist
+		from, to uint
+	}{{p, 0, at}, {insert, 0, insert.Count()}, {p, at, p.Count()}} {
+		if err = j.append(r.src, r.from, r.to); err != nil {
+			return nil, err
+		}
+	}
+	return j.finish(p.Closed), nil
+}
+
+// joiner builds a playlist from ranges of segments of other playlists.
+type joiner struct {
+	out  *MediaPlaylist
+	c    *cloner
+	key  *Key   // key in force for the last appended segment
+	keys []*Key // other keys of the set in force for the last appended segment
+	xmap *Map   // map in force for the last appended segment
+}
+
+// newJoiner creates the joiner with the header copied from `head` and
+// enough capacity for all segments of `sources`.
+func newJoiner(head *MediaPlaylist, sources ...*MediaPlaylist) (*joiner, error) {
+	var capacity uint
+	for _, src := range sources {
+		capacity += src.Count()
+	}
+	if capacity == 0 {
+		return nil, errors.New("no segments to join")
+	}
+	out, err := NewMediaPlaylist(0, capacity)
+	if err != nil {
+		return nil, err
+	}
+	head.copyHeaderTo(out)
+	for _, src := range sources {
+		version(&out.ver, src.ver)
+	}
+	out.SeqNo = head.SeqNo
+	if first := head.First(); first != nil {
+		out.SeqNo = first.SeqId
+	}
+	out.DiscontinuitySeq = head.DiscontinuitySeq
+	return &joiner{out: out, c: newCloner()}, nil
+}
+
+// append copies segments of `src` from index `from` up to `to` to the
+// output. The first copied segment is marked as discontinuity if the
+// output is not empty.
+func (j *joiner) append(src *MediaPlaylist, from, to uint) error {
+	key, keys, xmap := src.Key, src.Keys, src.Map
+	for i, seg := range src.OrderedSegments() {
+		if seg.Key != nil {
+			key, keys = seg.Key, seg.Keys
+		}
+		if seg.Map != nil {
+			xmap = seg.Map
+		}
+		if uint(i) < from || uint(i) >= to {
+			continue
+		}
+		if xmap == nil && j.xmap != nil {
+			return ErrMapMismatch
+		}
+		cp := j.c.segment(seg)
+		cp.Key, cp.Keys, cp.Map = nil, nil, nil
+		if uint(i) == from && j.out.Count() > 0 {
+			cp.Discontinuity = true
+		}
+		if !sameKeySet(j.key, j.keys, key, keys) {
+			if key == nil {
+				cp.Key = &Key{Method: "NONE"}
+			} else {
+				cp.Key, cp.Keys = j.c.key(key), j.c.keyList(keys)
+			}
+			j.key, j.keys = key, keys
+		}
+		if xmap != nil && (j.xmap == nil || *j.xmap != *xmap) {
+			cp.Map = j.c.xmap(xmap)
+			j.xmap = xmap
+		}
+		// capacity is preallocated for all the segments
+		_ = j.out.AppendSegment(cp)
+	}
+	return nil
+}
+
+func (j *joiner) finish(closed bool) *MediaPlaylist {
+	if closed {
+		j.out.MediaType = VOD
+		j.out.Close()
+	}
+	return j.out
+}
+
+// sameKeySet compares sets of keys by value regardless of the order of
+// keys. Absent key is the same as the key with METHOD=NONE, other keys
+// of the set are ignored for it.
+func sameKeySet(a *Key, aKeys []*Key, b *Key, bKeys []*Key) bool {
+	as, bs := keySet(a, aKeys), keySet(b, bKeys)
+	if len(as) != len(bs) {
+		return false
+	}
+outer:
+	for _, ka := range as {
+		for _, kb := range bs {
+			if *ka == *kb {
+				continue outer
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// keySet returns keys of the set, the set is empty for the absent key
+// and for METHOD=NONE.
+func keySet(key *Key, keys []*Key) []*Key {
+	if key == nil || key.Method == "NONE" {
+		return nil
+	}
+	set := []*Key{key}
+	for _, k := range keys {
+		if k != nil {
+			set = append(set, k)
+		}
+	}
+	return set
+}
+
+// containsKeyformat reports whether the set has the key of KEYFORMAT, the
+// absent KEYFORMAT means "identity".
+func containsKeyformat(set []*Key, keyformat string) bool {
+	if keyformat == "" {
+		keyformat = "identity"
+	}
+	for _, k := range set {
+		if k.Keyformat == keyformat || k.Keyformat == "" && keyformat == "identity" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+/*
+ Media playlist editing tests.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClipMediaPlaylist(t *testing.T) {
+	p, e := NewMediaPlaylist(0, 10)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	p.SeqNo = 100
+	p.DiscontinuitySeq = 2
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 8; i++ {
+		_ = p.Append(fmt.Sprintf("test%d.ts", i), 10.0, "")
+		switch i {
+		case 0:
+			_ = p.SetProgramDateTime(start)
+			_ = p.SetKey("AES-128", "key1.bin", "", "", "")
+			_ = p.SetMap("init1.mp4", 0, 0)
+		case 2:
+			_ = p.SetDiscontinuity()
+			_ = p.SetProgramDateTime(start.Add(20 * time.Second))
+			_ = p.SetKey("AES-128", "key2.bin", "", "", "")
+		case 5:
+			_ = p.SetMap("init2.mp4", 0, 0)
+		}
+	}
+	p.Close()
+
+	clip, err := p.Clip(32.5, 55)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clip.Count() != 3 || clip.First().URI != "test3.ts" || clip.Last().URI != "test5.ts" {
+		t.Fatalf("Unexpected clip segments:\n%s", clip)
+	}
+	if clip.SeqNo != 103 || clip.First().SeqId != 103 || clip.DiscontinuitySeq != 3 {
+		t.Errorf("Unexpected sequence numbers: %d/%d/%d", clip.SeqNo, clip.First().SeqId, clip.DiscontinuitySeq)
+	}
+	if clip.StartTime != 2.5 || !clip.StartTimePrecise {
+		t.Errorf("Unexpected start: %v/%v", clip.StartTime, clip.StartTimePrecise)
+	}
+	if !clip.Closed || clip.MediaType != VOD || clip.TargetDuration != 10 {
+		t.Errorf("Clip must be a closed VOD playlist: %v/%v/%v", clip.Closed, clip.MediaType, clip.TargetDuration)
+	}
+	expected := `#EXT-X-START:TIME-OFFSET=2.5,PRECISE=YES
+#EXT-X-DISCONTINUITY-SEQUENCE:3
+#EXT-X-KEY:METHOD=AES-128,URI="key2.bin"
+#EXT-X-MAP:URI="init1.mp4"
+#EXT-X-PROGRAM-DATE-TIME:2020-01-01T00:00:30Z
+#EXTINF:10.000,
+test3.ts
+#EXTINF:10.000,
+test4.ts
+#EXT-X-MAP:URI="init2.mp4"
+#EXTINF:10.000,
+test5.ts
+#EXT-X-ENDLIST
+`
+	if !strings.HasSuffix(clip.String(), expected) {
+		t.Errorf("Unexpected clip:\n%s\nexpected suffix:\n%s", clip, expected)
+	}
+	clip.First().URI = "changed.ts"
+	if p.Segments[3].URI != "test3.ts" {
+		t.Error("Changes of the clip affected the original playlist")
+	}
+}
+
+func TestClipMediaPlaylistOutOfRange(t *testing.T) {
+	p, _ := NewMediaPlaylist(0, 2)
+	_ = p.Append("test0.ts", 10.0, "")
+	_ = p.Append("test1.ts", 10.0, "")
+	if _, err := p.Clip(20, 30); err != ErrOutOfRange {
+		t.Errorf("Expected ErrOutOfRange, got: %v", err)
+	}
+	if _, err := p.Clip(5, 5); err == nil {
+		t.Error("Expected error for empty range")
+	}
+	clip, err := p.Clip(0, 100)
+	if err != nil || clip.Count() != 2 || clip.StartTime != 0 {
+		t.Errorf("Clip over the end must include all segments: %v", err)
+	}
+}
+
+func TestConcatMediaPlaylists(t *testing.T) {
+	a, _ := NewMediaPlaylist(0, 2)
+	a.SeqNo = 10
+	_ = a.Append("a0.ts", 6.0, "")
+	_ = a.SetKey("AES-128", "key1.bin", "", "", "")
+	_ = a.Append("a1.ts", 6.0, "")
+	a.Close()
+	b, _ := NewMediaPlaylist(0, 2)
+	_ = b.Append("b0.ts", 9.5, "")
+	_ = b.Append("b1.ts", 6.0, "")
+	b.Close()
+	c, _ := NewMediaPlaylist(0, 1)
+	_ = c.Append("c0.ts", 6.0, "")
+	_ = c.SetKey("AES-128", "key1.bin", "", "", "")
+	c.Close()
+
+	p, err := Concat(a, b, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Count() != 5 || p.SeqNo != 10 || p.Last().SeqId != 14 || p.TargetDuration != 10 {
+		t.Errorf("Unexpected joined playlist: %d/%d/%d/%v", p.Count(), p.SeqNo, p.Last().SeqId, p.TargetDuration)
+	}
+	if !p.Closed || p.MediaType != VOD {
+		t.Error("Joined playlist of closed playlists must be closed")
+	}
+	expected := `#EXT-X-KEY:METHOD=AES-128,URI="key1.bin"
+#EXTINF:6.000,
+a0.ts
+#EXTINF:6.000,
+a1.ts
+#EXT-X-KEY:METHOD=NONE
+#EXT-X-DISCONTINUITY
+#EXTINF:9.500,
+b0.ts
+#EXTINF:6.000,
+b1.ts
+#EXT-X-KEY:METHOD=AES-128,URI="key1.bin"
+#EXT-X-DISCONTINUITY
+#EXTINF:6.000,
+c0.ts
+#EXT-X-ENDLIST
+`
+	if !strings.HasSuffix(p.String(), expected) {
+		t.Errorf("Unexpected joined playlist:\n%s\nexpected suffix:\n%s", p, expected)
+	}
+	if _, err = Concat(); err == nil {
+		t.Error("Concat without playlists must fail")
+	}
+}
+
+func TestConcatMediaPlaylistsWithoutMap(t *testing.T) {
+	fmp4, _ := NewMediaPlaylist(0, 1)
+	fmp4.Map = &Map{URI: "init.mp4"}
+	_ = fmp4.Append("a.m4s", 6.0, "")
+	fmp4.Close()
+	ts, _ := NewMediaPlaylist(0, 1)
+	_ = ts.Append("b.ts", 6.0, "")
+	ts.Close()
+
+	if _, err := Concat(fmp4, ts); err != ErrMapMismatch {
+		t.Errorf("Expected ErrMapMismatch, got: %v", err)
+	}
+	if _, err := ts.SpliceAt(0, fmp4); err != ErrMapMismatch {
+		t.Errorf("Expected ErrMapMismatch, got: %v", err)
+	}
+	// the map may start after the segments without it
+	p, err := Concat(ts, fmp4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `#EXT-X-DISCONTINUITY
+#EXT-X-MAP:URI="init.mp4"
+#EXTINF:6.000,
+a.m4s
+`
+	if !strings.Contains(p.String(), expected) {
+		t.Errorf("Unexpected joined playlist:\n%s\nexpected:\n%s", p, expected)
+	}
+}
+
+func TestSpliceMediaPlaylist(t *testing.T) {
+	p, _ := NewMediaPlaylist(0, 4)
+	for i := 0; i < 4; i++ {
+		_ = p.Append(fmt.Sprintf("main%d.ts", i), 10.0, "")
+	}
+	p.Map = &Map{URI: "main.mp4"}
+	p.Close()
+	ad, _ := NewMediaPlaylist(0, 2)
+	ad.Map = &Map{URI: "ad.mp4"}
+	_ = ad.Append("ad0.ts", 5.0, "")
+	_ = ad.Append("ad1.ts", 5.0, "")
+
+	expected := `#EXT-X-MAP:URI="main.mp4"
+#EXTINF:10.000,
+main0.ts
+#EXTINF:10.000,
+main1.ts
+#EXT-X-DISCONTINUITY
+#EXT-X-MAP:URI="ad.mp4"
+#EXTINF:5.000,
+ad0.ts
+#EXTINF:5.000,
+ad1.ts
+#EXT-X-DISCONTINUITY
+#EXT-X-MAP:URI="main.mp4"
+#EXTINF:10.000,
+main2.ts
+#EXTINF:10.000,
+main3.ts
+#EXT-X-ENDLIST
+`
+	for _, offset := range []float64{15, 20} {
+		s, err := p.SpliceAtTime(offset, ad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(s.String(), expected) {
+			t.Errorf("Unexpected spliced playlist at %v:\n%s\nexpected suffix:\n%s", offset, s, expected)
+		}
+	}
+	s, err := p.SpliceAt(2, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Count() != 6 || s.Last().SeqId != 5 || !strings.HasSuffix(s.String(), expected) {
+		t.Errorf("Unexpected spliced playlist:\n%s", s)
+	}
+	if _, err = p.SpliceAt(10, ad); err != ErrOutOfRange {
+		t.Errorf("Unexpected error for unknown segment: %v", err)
+	}
+	if _, err = p.SpliceAtTime(41, ad); err != ErrOutOfRange {
+		t.Errorf("Unexpected error for offset out of range: %v", err)
+	}
+}
@@ -0,0 +1,240 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines selection and ordering of variants of master
+ playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"sort"
+)
+
+// VariantFilter describes the variants which may be played by a
+// device. Zero fields don't restrict variants.
+type VariantFilter struct {
+	// MinBandwidth and MaxBandwidth limit BANDWIDTH of regular
+	// variants. I-frame variants are not limited by bandwidth as it is
+	// not comparable with bandwidth of regular variants, they follow
+	// the regular variants of the same resolution in FilterVariants.
+	MinBandwidth uint32
+	MaxBandwidth uint32
+	// MaxResolution caps the width and the height of the video.
+	// Variants without RESOLUTION are not capped.
+	MaxResolution Resolution
+	// CodecFamilies lists the supported families of codecs as returned
+	// by Codec.Family, for example "avc", "hevc", "aac", "ec-3".
+	// Variants with any codec of another family are dropped, variants
+	// without CODECS are kept.
+	CodecFamilies []string
+	// NoHDR drops variants with HLG and PQ video ranges.
+	NoHDR bool
+	// MaxFrameRate drops variants with higher FRAME-RATE.
+	MaxFrameRate float64
+	// MaxHDCPLevel is the highest supported HDCP level, NONE drops all
+	// variants which require HDCP. Variants without HDCP-LEVEL don't
+	// require HDCP.
+	MaxHDCPLevel HDCPLevel
+}
+
+// Match reports whether the variant satisfies the filter.
+func (f *VariantFilter) Match(v *Variant) bool {
+	if v.Bandwidth < f.MinBandwidth && !v.Iframe {
+		return false
+	}
+	if f.MaxBandwidth > 0 && v.Bandwidth > f.MaxBandwidth && !v.Iframe {
+		return false
+	}
+	if f.MaxResolution.Width > 0 || f.MaxResolution.Height > 0 {
+		if r, err := v.ResolutionValue(); err == nil &&
+			(f.MaxResolution.Width > 0 && r.Width > f.MaxResolution.Width ||
+				f.MaxResolution.Height > 0 && r.Height > f.MaxResolution.Height) {
+			return false
+		}
+	}
+	if len(f.CodecFamilies) > 0 && v.Codecs != "" {
+		codecs, _ := ParseCodecs(v.Codecs, false)
+		for _, c := range codecs {
+			if !containsString(f.CodecFamilies, c.Family()) {
+				return false
+			}
+		}
+	}
+	if f.NoHDR && VideoRange(v.VideoRange).HDR() {
+		return false
+	}
+	if f.MaxFrameRate > 0 && v.FrameRate > f.MaxFrameRate {
+		return false
+	}
+	if f.MaxHDCPLevel != "" && hdcpRank(HDCPLevel(v.HDCPLevel)) > hdcpRank(f.MaxHDCPLevel) {
+		return false
+	}
+	return true
+}
+
+// hdcpRank orders HDCP levels from no protection to the strongest one.
+func hdcpRank(l HDCPLevel) int {
+	switch l {
+	case HDCPType0:
+		return 1
+	case HDCPType1:
+		return 2
+	}
+	return 0
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterVariants keeps the variants for which the function returns
+// true and drops others. The filter may be used as the function:
+//
+//	p.FilterVariants(filter.Match)
+//
+// I-frame variants are kept only along with the regular variants of
+// the same resolution when the resolutions are known. EXT-X-MEDIA
+// groups which are not referenced by the remaining variants are
+// pruned, the groups which are still referenced remain in the
+// playlist even if they were attached to the dropped variants. This
+// operation does reset playlist cache.
+func (p *MasterPlaylist) FilterVariants(keep func(v *Variant) bool) {
+	var (
+		alts       []*Alternative // all alternatives in the order of the playlist
+		kept       []*Variant
+		resolution = make(map[string]bool) // resolutions of the kept regular variants
+	)
+	for _, v := range p.Variants {
+		if v == nil {
+			continue
+		}
+		alts = append(alts, v.Alternatives...)
+		if keep(v) {
+			kept = append(kept, v)
+			if !v.Iframe && v.Resolution != "" {
+				resolution[v.Resolution] = true
+			}
+		}
+	}
+	variants := kept[:0]
+	for _, v := range kept {
+		if v.Iframe && v.Resolution != "" && len(resolution) > 0 && !resolution[v.Resolution] {
+			continue
+		}
+		variants = append(variants, v)
+	}
+	p.Variants = variants
+	p.pruneAlternatives(alts)
+	p.buf.Reset()
+}
+
+// pruneAlternatives attaches the alternatives of the referenced groups
+// to the variants referencing them and drops others, nil alternatives
+// are dropped too.
+func (p *MasterPlaylist) pruneAlternatives(alts []*Alternative) {
+	type group struct{ kind, id string }
+	referenced := make(map[group]bool)
+	for _, v := range p.Variants {
+		for _, g := range []group{{"VIDEO", v.Video}, {"AUDIO", v.Audio}, {"SUBTITLES", v.Subtitles}, {"CLOSED-CAPTIONS", v.Captions}} {
+			if g.id != "" {
+				referenced[g] = true
+			}
+		}
+	}
+	attached := make(map[*Alternative]bool)
+	for _, v := range p.Variants {
+		var keep []*Alternative
+		for _, alt := range v.Alternatives {
+			if alt != nil && referenced[group{alt.Type, alt.GroupId}] {
+				keep = append(keep, alt)
+				attached[alt] = true
+			}
+		}
+		v.Alternatives = keep
+	}
+	// alternatives of the dropped variants go to the first variant
+	// which references their group
+	for _, alt := range alts {
+		if alt == nil {
+			continue
+		}
+		g := group{alt.Type, alt.GroupId}
+		if attached[alt] || !referenced[g] {
+			continue
+		}
+		for _, v := range p.Variants {
+			if g == (group{"VIDEO", v.Video}) || g == (group{"AUDIO", v.Audio}) ||
+				g == (group{"SUBTITLES", v.Subtitles}) || g == (group{"CLOSED-CAPTIONS", v.Captions}) {
+				v.Alternatives = append(v.Alternatives, alt)
+				break
+			}
+		}
+		attached[alt] = true
+	}
+}
+
+// SortVariants sorts the variants with the less function. Regular and
+// I-frame variants are sorted separately and keep their places in the
+// playlist, the order of equal variants is kept. Players usually start
+// from the first listed variant. This operation does reset playlist
+// cache.
+func (p *MasterPlaylist) SortVariants(less func(a, b *Variant) bool) {
+	var regular, iframe []*Variant
+	for _, v := range p.Variants {
+		if v != nil && v.Iframe {
+			iframe = append(iframe, v)
+		} else {
+			regular = append(regular, v)
+		}
+	}
+	for _, list := range [][]*Variant{regular, iframe} {
+		list := list
+		sort.SliceStable(list, func(i, j int) bool {
+			return list[i] != nil && list[j] != nil && less(list[i], list[j])
+		})
+	}
+	for i, v := range p.Variants {
+		if v != nil && v.Iframe {
+			p.Variants[i], iframe = iframe[0], iframe[1:]
+		} else {
+			p.Variants[i], regular = regular[0], regular[1:]
+		}
+	}
+	p.buf.Reset()
+}
+
+// SortByBandwidth sorts the variants by BANDWIDTH in ascending or
+// descending order.
+func (p *MasterPlaylist) SortByBandwidth(descending bool) {
+	p.SortVariants(func(a, b *Variant) bool {
+		if descending {
+			return a.Bandwidth > b.Bandwidth
+		}
+		return a.Bandwidth < b.Bandwidth
+	})
+}
+
+// SortByScore sorts the variants by the score in descending order so
+// the variant with the highest score is listed first.
+func (p *MasterPlaylist) SortByScore(score func(v *Variant) float64) {
+	scores := make(map[*Variant]float64, len(p.Variants))
+	for _, v := range p.Variants {
+		if v != nil {
+			scores[v] = score(v)
+		}
+	}
+	p.SortVariants(func(a, b *Variant) bool {
+		return scores[a] > scores[b]
+	})
+}
@@ -0,0 +1,158 @@
+/*
+ Variant filtering and sorting tests.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const filterMaster = `#EXTM3U
+#EXT-X-VERSION:6
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",LANGUAGE="en",DEFAULT=YES,URI="aac.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="ec3",NAME="English",LANGUAGE="en",DEFAULT=YES,URI="ec3.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=800000,RESOLUTION=640x360,CODECS="avc1.4d401e,mp4a.40.2",AUDIO="aac"
+360.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=5000000,RESOLUTION=1920x1080,CODECS="hvc1.2.4.L123.B0,ec-3",AUDIO="ec3",VIDEO-RANGE=PQ,HDCP-LEVEL=TYPE-1,FRAME-RATE=60.000
+1080.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=2000000,RESOLUTION=1280x720,CODECS="avc1.4d401f,mp4a.40.2",AUDIO="aac",HDCP-LEVEL=TYPE-0
+720.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=100000,RESOLUTION=640x360,CODECS="avc1.4d401e",URI="360-iframe.m3u8"
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=500000,RESOLUTION=1920x1080,CODECS="hvc1.2.4.L123.B0",URI="1080-iframe.m3u8"
+`
+
+func decodeFilterMaster(t *testing.T) *MasterPlaylist {
+	p := NewMasterPlaylist()
+	if err := p.DecodeFrom(bytes.NewBufferString(filterMaster), true); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func variantURIs(p *MasterPlaylist) string {
+	var uris []string
+	for _, v := range p.Variants {
+		uris = append(uris, v.URI)
+	}
+	return strings.Join(uris, " ")
+}
+
+func TestVariantFilterMatch(t *testing.T) {
+	p := decodeFilterMaster(t)
+	cases := []struct {
+		filter VariantFilter
+		want   string
+	}{
+		{VariantFilter{}, "360.m3u8 1080.m3u8 720.m3u8 360-iframe.m3u8 1080-iframe.m3u8"},
+		{VariantFilter{MinBandwidth: 1000000, MaxBandwidth: 3000000}, "720.m3u8 360-iframe.m3u8 1080-iframe.m3u8"},
+		{VariantFilter{MinBandwidth: 1000000}, "1080.m3u8 720.m3u8 360-iframe.m3u8 1080-iframe.m3u8"},
+		{VariantFilter{MaxBandwidth: 50000}, "360-iframe.m3u8 1080-iframe.m3u8"},
+		{VariantFilter{MaxResolution: Resolution{Height: 720}}, "360.m3u8 720.m3u8 360-iframe.m3u8"},
+		{VariantFilter{CodecFamilies: []string{"avc", "aac"}}, "360.m3u8 720.m3u8 360-iframe.m3u8"},
+		{VariantFilter{NoHDR: true}, "360.m3u8 720.m3u8 360-iframe.m3u8 1080-iframe.m3u8"},
+		{VariantFilter{MaxFrameRate: 30}, "360.m3u8 720.m3u8 360-iframe.m3u8 1080-iframe.m3u8"},
+		{VariantFilter{MaxHDCPLevel: HDCPNone}, "360.m3u8 360-iframe.m3u8 1080-iframe.m3u8"},
+		{VariantFilter{MaxHDCPLevel: HDCPType0}, "360.m3u8 720.m3u8 360-iframe.m3u8 1080-iframe.m3u8"},
+	}
+	for i, c := range cases {
+		var got []string
+		for _, v := range p.Variants {
+			if c.filter.Match(v) {
+				got = append(got, v.URI)
+			}
+		}
+		if strings.Join(got, " ") != c.want {
+			t.Errorf("case %d: got %q, want %q", i, strings.Join(got, " "), c.want)
+		}
+	}
+}
+
+func TestFilterVariantsPrunesGroups(t *testing.T) {
+	p := decodeFilterMaster(t)
+	f := VariantFilter{CodecFamilies: []string{"avc", "aac"}}
+	p.FilterVariants(f.Match)
+	if got := variantURIs(p); got != "360.m3u8 720.m3u8 360-iframe.m3u8" {
+		t.Fatalf("Unexpected variants: %s", got)
+	}
+	out := p.String()
+	if strings.Contains(out, `GROUP-ID="ec3"`) {
+		t.Errorf("Orphaned group is not pruned:\n%s", out)
+	}
+	if strings.Count(out, `GROUP-ID="aac"`) != 1 {
+		t.Errorf("Referenced group is lost or duplicated:\n%s", out)
+	}
+	if strings.Contains(out, "1080") {
+		t.Errorf("Dropped variants are written:\n%s", out)
+	}
+}
+
+func TestFilterVariantsKeepsGroupsOfDroppedVariants(t *testing.T) {
+	p := decodeFilterMaster(t)
+	// the group is attached by the decoder to the first variant
+	// referencing it, here the variant is dropped
+	p.FilterVariants(func(v *Variant) bool { return v.URI != "360.m3u8" })
+	out := p.String()
+	if strings.Count(out, `GROUP-ID="aac"`) != 1 || strings.Count(out, `GROUP-ID="ec3"`) != 1 {
+		t.Errorf("Referenced groups are lost:\n%s", out)
+	}
+	if strings.Index(out, `GROUP-ID="aac"`) > strings.Index(out, "720.m3u8") {
+		t.Errorf("Group is written after its variant:\n%s", out)
+	}
+	if strings.Contains(out, "360-iframe.m3u8") {
+		t.Errorf("I-frame variant without regular variant is kept:\n%s", out)
+	}
+}
+
+func TestFilterVariantsSkipsNilAlternatives(t *testing.T) {
+	p := decodeFilterMaster(t)
+	p.Variants[0].Alternatives = append(p.Variants[0].Alternatives, nil)
+	p.Variants[2].Alternatives = append([]*Alternative{nil}, p.Variants[2].Alternatives...)
+	p.FilterVariants(func(v *Variant) bool { return v.URI != "360.m3u8" })
+	for _, v := range p.Variants {
+		for _, alt := range v.Alternatives {
+			if alt == nil {
+				t.Fatalf("Nil alternative of %s is kept", v.URI)
+			}
+		}
+	}
+	if out := p.String(); strings.Count(out, `GROUP-ID="aac"`) != 1 {
+		t.Errorf("Referenced group is lost:\n%s", out)
+	}
+}
+
+func TestSortVariants(t *testing.T) {
+	p := decodeFilterMaster(t)
+	p.SortByBandwidth(false)
+	if got := variantURIs(p); got != "360.m3u8 720.m3u8 1080.m3u8 360-iframe.m3u8 1080-iframe.m3u8" {
+		t.Errorf("Unexpected ascending order: %s", got)
+	}
+	p.SortByBandwidth(true)
+	if got := variantURIs(p); got != "1080.m3u8 720.m3u8 360.m3u8 1080-iframe.m3u8 360-iframe.m3u8" {
+		t.Errorf("Unexpected descending order: %s", got)
+	}
+	// prefer 720p to start playback
+	p.SortByScore(func(v *Variant) float64 {
+		r, _ := v.ResolutionValue()
+		if r.Height == 720 {
+			return 1
+		}
+		return 0
+	})
+	if got := variantURIs(p); got != "720.m3u8 1080.m3u8 360.m3u8 1080-iframe.m3u8 360-iframe.m3u8" {
+		t.Errorf("Unexpected order by score: %s", got)
+	}
+	p.Encode() // fill the cache
+	p.SortByBandwidth(false)
+	out := p.String()
+	if strings.Index(out, "360.m3u8") > strings.Index(out, "720.m3u8") {
+		t.Errorf("Cache is not reset:\n%s", out)
+	}
+}
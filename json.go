@@ -0,0 +1,640 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines functions related to JSON marshaling of playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Custom tags are interfaces so they marshaled to JSON in their
+// encoded M3U8 form and restored with decoders from the registry.
+var customTagRegistry = struct {
+	sync.RWMutex
+	decoders map[string]CustomDecoder
+}{decoders: make(map[string]CustomDecoder)}
+
+// RegisterCustomTag registers the decoder used for restoring of
+// custom tags with the decoder's TagName from JSON. Registering of
+// the decoder for the same tag name replaces the previous one.
+func RegisterCustomTag(decoder CustomDecoder) {
+	customTagRegistry.Lock()
+	customTagRegistry.decoders[decoder.TagName()] = decoder
+	customTagRegistry.Unlock()
+}
+
+// UnregisterCustomTag removes the decoder for the tag name from the
+// registry.
+func UnregisterCustomTag(tagName string) {
+	customTagRegistry.Lock()
+	delete(customTagRegistry.decoders, tagName)
+	customTagRegistry.Unlock()
+}
+
+func marshalCustom(custom map[string]CustomTag) map[string]string {
+	if len(custom) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(custom))
+	for name, tag := range custom {
+		if tag != nil {
+			out[name] = tag.String()
+		}
+	}
+	return out
+}
+
+func unmarshalCustom(in map[string]string) (map[string]CustomTag, error) {
+	if in == nil {
+		return nil, nil
+	}
+	out := make(map[string]CustomTag, len(in))
+	customTagRegistry.RLock()
+	defer customTagRegistry.RUnlock()
+	for name, line := range in {
+		decoder, ok := customTagRegistry.decoders[name]
+		if !ok {
+			return nil, fmt.Errorf("no registered decoder for custom tag %s", name)
+		}
+		tag, err := decoder.Decode(line)
+		if err != nil {
+			return nil, err
+		}
+		out[name] = tag
+	}
+	return out, nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (t MediaType) MarshalText() ([]byte, error) {
+	switch t {
+	case 0:
+		return []byte{}, nil
+	case EVENT:
+		return []byte("EVENT"), nil
+	case VOD:
+		return []byte("VOD"), nil
+	}
+	return nil, fmt.Errorf("unknown media type %d", t)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (t *MediaType) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "":
+		*t = 0
+	case "EVENT":
+		*t = EVENT
+	case "VOD":
+		*t = VOD
+	default:
+		return fmt.Errorf("unknown media type %q", text)
+	}
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s SCTE35Syntax) MarshalText() ([]byte, error) {
+	switch s {
+	case SCTE35_67_2014:
+		return []byte("SCTE35_67_2014"), nil
+	case SCTE35_OATCLS:
+		return []byte("SCTE35_OATCLS"), nil
+	case SCTE35_ELEMENTAL:
+		return []byte("SCTE35_ELEMENTAL"), nil
+	case SCTE35_ANVATO:
+		return []byte("SCTE35_ANVATO"), nil
+	case SCTE35_ADOBE:
+		return []byte("SCTE35_ADOBE"), nil
+	case SCTE35_SPLICEPOINT:
+		return []byte("SCTE35_SPLICEPOINT"), nil
+	case SCTE35_DATERANGE:
+		return []byte("SCTE35_DATERANGE"), nil
+	}
+	return nil, fmt.Errorf("unknown SCTE-35 syntax %d", s)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *SCTE35Syntax) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "SCTE35_67_2014":
+		*s = SCTE35_67_2014
+	case "SCTE35_OATCLS":
+		*s = SCTE35_OATCLS
+	case "SCTE35_ELEMENTAL":
+		*s = SCTE35_ELEMENTAL
+	case "SCTE35_ANVATO":
+		*s = SCTE35_ANVATO
+	case "SCTE35_ADOBE":
+		*s = SCTE35_ADOBE
+	case "SCTE35_SPLICEPOINT":
+		*s = SCTE35_SPLICEPOINT
+	case "SCTE35_DATERANGE":
+		*s = SCTE35_DATERANGE
+	default:
+		return fmt.Errorf("unknown SCTE-35 syntax %q", text)
+	}
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (c SCTE35CueType) MarshalText() ([]byte, error) {
+	switch c {
+	case SCTE35Cue_Start:
+		return []byte("START"), nil
+	case SCTE35Cue_Mid:
+		return []byte("MID"), nil
+	case SCTE35Cue_End:
+		return []byte("END"), nil
+	}
+	return nil, fmt.Errorf("unknown SCTE-35 cue type %d", c)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (c *SCTE35CueType) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "START":
+		*c = SCTE35Cue_Start
+	case "MID":
+		*c = SCTE35Cue_Mid
+	case "END":
+		*c = SCTE35Cue_End
+	default:
+		return fmt.Errorf("unknown SCTE-35 cue type %q", text)
+	}
+	return nil
+}
+
+type keyJSON struct {
+	Method            string `json:"method"`
+	URI               string `json:"uri,omitempty"`
+	IV                string `json:"iv,omitempty"`
+	Keyformat         string `json:"keyformat,omitempty"`
+	Keyformatversions string `json:"keyformatversions,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (k *Key) MarshalJSON() ([]byte, error) {
+	return json.Marshal(keyJSON(*k))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (k *Key) UnmarshalJSON(data []byte) error {
+	var v keyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*k = Key(v)
+	return nil
+}
+
+type mapJSON struct {
+	URI    string `json:"uri"`
+	Limit  int64  `json:"limit,omitempty"`
+	Offset int64  `json:"offset,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m *Map) MarshalJSON() ([]byte, error) {
+	return json.Marshal(mapJSON(*m))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (m *Map) UnmarshalJSON(data []byte) error {
+	var v mapJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*m = Map(v)
+	return nil
+}
+
+type scteJSON struct {
+	Syntax  SCTE35Syntax  `json:"syntax"`
+	CueType SCTE35CueType `json:"cueType"`
+	Cue     string        `json:"cue,omitempty"`
+	ID      string        `json:"id,omitempty"`
+	Time    float64       `json:"time,omitempty"`
+	Elapsed float64       `json:"elapsed,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s *SCTE) MarshalJSON() ([]byte, error) {
+	return json.Marshal(scteJSON(*s))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *SCTE) UnmarshalJSON(data []byte) error {
+	var v scteJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*s = SCTE(v)
+	return nil
+}
+
+type wvJSON struct {
+	AudioChannels          uint   `json:"audioChannels,omitempty"`
+	AudioFormat            uint   `json:"audioFormat,omitempty"`
+	AudioProfileIDC        uint   `json:"audioProfileIDC,omitempty"`
+	AudioSampleSize        uint   `json:"audioSampleSize,omitempty"`
+	AudioSamplingFrequency uint   `json:"audioSamplingFrequency,omitempty"`
+	CypherVersion          string `json:"cypherVersion,omitempty"`
+	ECM                    string `json:"ecm,omitempty"`
+	VideoFormat            uint   `json:"videoFormat,omitempty"`
+	VideoFrameRate         uint   `json:"videoFrameRate,omitempty"`
+	VideoLevelIDC          uint   `json:"videoLevelIDC,omitempty"`
+	VideoProfileIDC        uint   `json:"videoProfileIDC,omitempty"`
+	VideoResolution        string `json:"videoResolution,omitempty"`
+	VideoSAR               string `json:"videoSAR,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (wv *WV) MarshalJSON() ([]byte, error) {
+	return json.Marshal(wvJSON(*wv))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (wv *WV) UnmarshalJSON(data []byte) error {
+	var v wvJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*wv = WV(v)
+	return nil
+}
+
+type alternativeJSON struct {
+	Type            string `json:"type"`
+	URI             string `json:"uri,omitempty"`
+	GroupId         string `json:"groupId"`
+	Language        string `json:"language,omitempty"`
+	Name            string `json:"name,omitempty"`
+	Default         bool   `json:"default,omitempty"`
+	Autoselect      string `json:"autoselect,omitempty"`
+	Forced          string `json:"forced,omitempty"`
+	InstreamId      string `json:"instreamId,omitempty"`
+	Characteristics string `json:"characteristics,omitempty"`
+	Channels        string `json:"channels,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (a *Alternative) MarshalJSON() ([]byte, error) {
+	return json.Marshal(alternativeJSON(*a))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (a *Alternative) UnmarshalJSON(data []byte) error {
+	var v alternativeJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*a = Alternative(v)
+	return nil
+}
+
+type variantJSON struct {
+	URI              string         `json:"uri"`
+	Chunklist        *MediaPlaylist `json:"chunklist,omitempty"`
+	ProgramId        uint32         `json:"programId,omitempty"`
+	Bandwidth        uint32         `json:"bandwidth"`
+	AverageBandwidth uint32         `json:"averageBandwidth,omitempty"`
+	Codecs           string         `json:"codecs,omitempty"`
+	Resolution       string         `json:"resolution,omitempty"`
+	Audio            string         `json:"audio,omitempty"`
+	Video            string         `json:"video,omitempty"`
+	Subtitles        string         `json:"subtitles,omitempty"`
+	Captions         string         `json:"captions,omitempty"`
+	Name             string         `json:"name,omitempty"`
+	Iframe           bool           `json:"iframe,omitempty"`
+	VideoRange       string         `json:"videoRange,omitempty"`
+	HDCPLevel        string         `json:"hdcpLevel,omitempty"`
+	FrameRate        float64        `json:"frameRate,omitempty"`
+	Alternatives     []*Alternative `json:"alternatives,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (v *Variant) MarshalJSON() ([]byte, error) {
+	return json.Marshal(variantJSON{
+		URI:              v.URI,
+		Chunklist:        v.Chunklist,
+		ProgramId:        v.ProgramId,
+		Bandwidth:        v.Bandwidth,
+		AverageBandwidth: v.AverageBandwidth,
+		Codecs:           v.Codecs,
+		Resolution:       v.Resolution,
+		Audio:            v.Audio,
+		Video:            v.Video,
+		Subtitles:        v.Subtitles,
+		Captions:         v.Captions,
+		Name:             v.Name,
+		Iframe:           v.Iframe,
+		VideoRange:       v.VideoRange,
+		HDCPLevel:        v.HDCPLevel,
+		FrameRate:        v.FrameRate,
+		Alternatives:     v.Alternatives,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (v *Variant) UnmarshalJSON(data []byte) error {
+	var in variantJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*v = Variant{
+		URI:       in.URI,
+		Chunklist: in.Chunklist,
+		VariantParams: VariantParams{
+			ProgramId:        in.ProgramId,
+			Bandwidth:        in.Bandwidth,
+			AverageBandwidth: in.AverageBandwidth,
+			Codecs:           in.Codecs,
+			Resolution:       in.Resolution,
+			Audio:            in.Audio,
+			Video:            in.Video,
+			Subtitles:        in.Subtitles,
+			Captions:         in.Captions,
+			Name:             in.Name,
+			Iframe:           in.Iframe,
+			VideoRange:       in.VideoRange,
+			HDCPLevel:        in.HDCPLevel,
+			FrameRate:        in.FrameRate,
+			Alternatives:     in.Alternatives,
+		},
+	}
+	return nil
+}
+
+type mediaSegmentJSON struct {
+	SeqId           uint64            `json:"seqId"`
+	Title           string            `json:"title,omitempty"`
+	URI             string            `json:"uri"`
+	Duration        float64           `json:"duration"`
+	Limit           int64             `json:"limit,omitempty"`
+	Offset          int64             `json:"offset,omitempty"`
+	Key             *Key              `json:"key,omitempty"`
+	Keys            []*Key            `json:"keys,omitempty"`
+	Map             *Map              `json:"map,omitempty"`
+	Discontinuity   bool              `json:"discontinuity,omitempty"`
+	SCTE            *SCTE             `json:"scte,omitempty"`
+	ProgramDateTime *time.Time        `json:"programDateTime,omitempty"`
+	Custom          map[string]string `json:"custom,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. Custom tags are marshaled
+// in their encoded form.
+func (seg *MediaSegment) MarshalJSON() ([]byte, error) {
+	out := mediaSegmentJSON{
+		SeqId:         seg.SeqId,
+		Title:         seg.Title,
+		URI:           seg.URI,
+		Duration:      seg.Duration,
+		Limit:         seg.Limit,
+		Offset:        seg.Offset,
+		Key:           seg.Key,
+		Keys:          seg.Keys,
+		Map:           seg.Map,
+		Discontinuity: seg.Discontinuity,
+		SCTE:          seg.SCTE,
+		Custom:        marshalCustom(seg.Custom),
+	}
+	if !seg.ProgramDateTime.IsZero() {
+		out.ProgramDateTime = &seg.ProgramDateTime
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Decoders for custom tags
+// must be registered with RegisterCustomTag.
+func (seg *MediaSegment) UnmarshalJSON(data []byte) error {
+	var in mediaSegmentJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	custom, err := unmarshalCustom(in.Custom)
+	if err != nil {
+		return err
+	}
+	*seg = MediaSegment{
+		SeqId:         in.SeqId,
+		Title:         in.Title,
+		URI:           in.URI,
+		Duration:      in.Duration,
+		Limit:         in.Limit,
+		Offset:        in.Offset,
+		Key:           in.Key,
+		Keys:          in.Keys,
+		Map:           in.Map,
+		Discontinuity: in.Discontinuity,
+		SCTE:          in.SCTE,
+		Custom:        custom,
+	}
+	if in.ProgramDateTime != nil {
+		seg.ProgramDateTime = *in.ProgramDateTime
+	}
+	return nil
+}
+
+type mediaPlaylistJSON struct {
+	Version          uint8             `json:"version"`
+	TargetDuration   float64           `json:"targetDuration"`
+	SeqNo            uint64            `json:"mediaSequence"`
+	DiscontinuitySeq uint64            `json:"discontinuitySequence,omitempty"`
+	MediaType        MediaType         `json:"playlistType,omitempty"`
+	Closed           bool              `json:"closed,omitempty"`
+	Iframe           bool              `json:"iframe,omitempty"`
+	StartTime        float64           `json:"startTime,omitempty"`
+	StartTimePrecise bool              `json:"startTimePrecise,omitempty"`
+	Args             string            `json:"args,omitempty"`
+	DurationAsInt    bool              `json:"durationAsInt,omitempty"`
+	WinSize          uint              `json:"winSize"`
+	Capacity         uint              `json:"capacity"`
+	BaseURL          string            `json:"baseURL,omitempty"`
+	Key              *Key              `json:"key,omitempty"`
+	Keys             []*Key            `json:"keys,omitempty"`
+	Map              *Map              `json:"map,omitempty"`
+	WV               *WV               `json:"wv,omitempty"`
+	Custom           map[string]string `json:"custom,omitempty"`
+	Segments         []*MediaSegment   `json:"segments"`
+}
+
+// MarshalJSON implements json.Marshaler. Segments are marshaled in
+// the playlist order from the head of the FIFO.
+func (p *MediaPlaylist) MarshalJSON() ([]byte, error) {
+	out := mediaPlaylistJSON{
+		Version:          p.ver,
+		TargetDuration:   p.TargetDuration,
+		SeqNo:            p.SeqNo,
+		DiscontinuitySeq: p.DiscontinuitySeq,
+		MediaType:        p.MediaType,
+		Closed:           p.Closed,
+		Iframe:           p.Iframe,
+		StartTime:        p.StartTime,
+		StartTimePrecise: p.StartTimePrecise,
+		Args:             p.Args,
+		DurationAsInt:    p.durationAsInt,
+		WinSize:          p.winsize,
+		Capacity:         p.capacity,
+		Key:              p.Key,
+		Keys:             p.Keys,
+		Map:              p.Map,
+		WV:               p.WV,
+		Custom:           marshalCustom(p.Custom),
+		Segments:         p.OrderedSegments(),
+	}
+	if p.BaseURL != nil {
+		out.BaseURL = p.BaseURL.String()
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The capacity of the
+// playlist is extended to fit all the segments if it is required.
+// Decoders for custom tags must be registered with RegisterCustomTag.
+func (p *MediaPlaylist) UnmarshalJSON(data []byte) error {
+	var in mediaPlaylistJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	custom, err := unmarshalCustom(in.Custom)
+	if err != nil {
+		return err
+	}
+	var base *url.URL
+	if in.BaseURL != "" {
+		if base, err = url.Parse(in.BaseURL); err != nil {
+			return err
+		}
+	}
+	var segments []*MediaSegment
+	for _, seg := range in.Segments {
+		if seg != nil {
+			segments = append(segments, seg)
+		}
+	}
+	capacity := in.Capacity
+	if capacity < uint(len(segments)) {
+		capacity = uint(len(segments))
+	}
+	if capacity < in.WinSize {
+		capacity = in.WinSize
+	}
+
+	// internal state of the receiver (automatic PDT, key rotation,
+	// target duration rounding) is dropped as for a new playlist
+	*p = MediaPlaylist{
+		ver:              in.Version,
+		TargetDuration:   in.TargetDuration,
+		SeqNo:            in.SeqNo,
+		DiscontinuitySeq: in.DiscontinuitySeq,
+		MediaType:        in.MediaType,
+		Closed:           in.Closed,
+		Iframe:           in.Iframe,
+		StartTime:        in.StartTime,
+		StartTimePrecise: in.StartTimePrecise,
+		Args:             in.Args,
+		durationAsInt:    in.DurationAsInt,
+		winsize:          in.WinSize,
+		capacity:         capacity,
+		BaseURL:          base,
+		Key:              in.Key,
+		Keys:             in.Keys,
+		Map:              in.Map,
+		WV:               in.WV,
+		Custom:           custom,
+		Segments:         make([]*MediaSegment, capacity),
+		count:            uint(len(segments)),
+	}
+	copy(p.Segments, segments)
+	if capacity > 0 {
+		p.tail = p.count % capacity
+	}
+	return nil
+}
+
+type masterPlaylistJSON struct {
+	Version             uint8             `json:"version"`
+	IndependentSegments bool              `json:"independentSegments,omitempty"`
+	Args                string            `json:"args,omitempty"`
+	CypherVersion       string            `json:"cypherVersion,omitempty"`
+	BaseURL             string            `json:"baseURL,omitempty"`
+	Custom              map[string]string `json:"custom,omitempty"`
+	Variants            []*Variant        `json:"variants"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p *MasterPlaylist) MarshalJSON() ([]byte, error) {
+	out := masterPlaylistJSON{
+		Version:             p.ver,
+		IndependentSegments: p.independentSegments,
+		Args:                p.Args,
+		CypherVersion:       p.CypherVersion,
+		Custom:              marshalCustom(p.Custom),
+		Variants:            p.Variants,
+	}
+	if p.BaseURL != nil {
+		out.BaseURL = p.BaseURL.String()
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Decoders for custom tags
+// must be registered with RegisterCustomTag.
+func (p *MasterPlaylist) UnmarshalJSON(data []byte) error {
+	var in masterPlaylistJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	custom, err := unmarshalCustom(in.Custom)
+	if err != nil {
+		return err
+	}
+	var base *url.URL
+	if in.BaseURL != "" {
+		if base, err = url.Parse(in.BaseURL); err != nil {
+			return err
+		}
+	}
+	// alternatives shared between variants are marshaled for each of
+	// them, equal ones are shared again
+	shared := make(map[[3]string]*Alternative)
+	for _, v := range in.Variants {
+		if v == nil {
+			continue
+		}
+		for i, alt := range v.Alternatives {
+			if alt == nil {
+				continue
+			}
+			key := [3]string{alt.Type, alt.GroupId, alt.Name}
+			if prev, ok := shared[key]; ok && *prev == *alt {
+				v.Alternatives[i] = prev
+			} else if !ok {
+				shared[key] = alt
+			}
+		}
+	}
+	*p = MasterPlaylist{
+		ver:                 in.Version,
+		independentSegments: in.IndependentSegments,
+		Args:                in.Args,
+		CypherVersion:       in.CypherVersion,
+		BaseURL:             base,
+		Custom:              custom,
+		Variants:            in.Variants,
+	}
+	return nil
+}
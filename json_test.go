@@ -0,0 +1,215 @@
+/*
+ Playlist JSON marshaling tests.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMediaPlaylistJSON(t *testing.T) {
+	RegisterCustomTag(&MockCustomTag{name: "#CUSTOM-PLAYLIST-TAG:", encodedString: "#CUSTOM-PLAYLIST-TAG:1"})
+	RegisterCustomTag(&MockCustomTag{name: "#CUSTOM-SEGMENT-TAG:", encodedString: "#CUSTOM-SEGMENT-TAG:1", segment: true})
+	defer UnregisterCustomTag("#CUSTOM-PLAYLIST-TAG:")
+	defer UnregisterCustomTag("#CUSTOM-SEGMENT-TAG:")
+
+	p, e := NewMediaPlaylist(3, 4)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	p.SetDefaultKey("AES-128", "key.bin", "0x10", "", "")
+	p.SetCustomTag(&MockCustomTag{name: "#CUSTOM-PLAYLIST-TAG:", encodedString: "#CUSTOM-PLAYLIST-TAG:1"})
+	p.MediaType = EVENT
+	for i := 0; i < 6; i++ {
+		p.Slide(fmt.Sprintf("test%d.ts", i), 5.5, "")
+	}
+	_ = p.SetProgramDateTime(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+	_ = p.SetSCTE35(&SCTE{Syntax: SCTE35_OATCLS, CueType: SCTE35Cue_Mid, Cue: "/DAl", Time: 30, Elapsed: 5})
+	_ = p.SetCustomSegmentTag(&MockCustomTag{name: "#CUSTOM-SEGMENT-TAG:", encodedString: "#CUSTOM-SEGMENT-TAG:1"})
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`"playlistType":"EVENT"`, `"syntax":"SCTE35_OATCLS"`, `"cueType":"MID"`, `"programDateTime":"2020-01-02T03:04:05Z"`} {
+		if !strings.Contains(string(data), s) {
+			t.Errorf("JSON does not contain %s:\n%s", s, data)
+		}
+	}
+	var raw struct{ Segments []*MediaSegment }
+	if err = json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if len(raw.Segments) != 3 || raw.Segments[0].URI != "test3.ts" || raw.Segments[2].URI != "test5.ts" {
+		t.Errorf("Segments must be marshaled in playlist order without holes: %s", data)
+	}
+
+	c := new(MediaPlaylist)
+	if err = json.Unmarshal(data, c); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Equal(c) {
+		d, _ := Diff(p, c)
+		t.Errorf("Unmarshaled playlist differs:\n%s", d)
+	}
+	if c.String() != p.String() {
+		t.Errorf("Encoded playlists differ:\n%s\nexpected:\n%s", c.String(), p.String())
+	}
+	c.Slide("test6.ts", 5.5, "")
+	if c.Count() != 3 || c.Segments[c.last()].SeqId != 6 {
+		t.Errorf("Unmarshaled playlist can not slide: count %d", c.Count())
+	}
+}
+
+func TestSCTE35SyntaxText(t *testing.T) {
+	for _, syntax := range []SCTE35Syntax{SCTE35_67_2014, SCTE35_OATCLS, SCTE35_ELEMENTAL, SCTE35_ANVATO, SCTE35_ADOBE, SCTE35_SPLICEPOINT, SCTE35_DATERANGE} {
+		text, err := syntax.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded SCTE35Syntax
+		if err = decoded.UnmarshalText(text); err != nil || decoded != syntax {
+			t.Errorf("Syntax %s decoded as %d (%v)", text, decoded, err)
+		}
+	}
+}
+
+func TestMediaPlaylistJSONUnregisteredCustomTag(t *testing.T) {
+	p, _ := NewMediaPlaylist(1, 1)
+	p.SetCustomTag(&MockCustomTag{name: "#UNKNOWN", encodedString: "#UNKNOWN"})
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(data, new(MediaPlaylist)); err == nil {
+		t.Error("Unmarshaling of unregistered custom tag must fail")
+	}
+}
+
+func TestMasterPlaylistJSON(t *testing.T) {
+	f, err := os.Open("sample-playlists/master-with-alternatives.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewMasterPlaylist()
+	if err = p.DecodeFrom(bufio.NewReader(f), false); err != nil {
+		t.Fatal(err)
+	}
+	chunklist, _ := NewMediaPlaylist(0, 1)
+	_ = chunklist.Append("test.ts", 5, "")
+	chunklist.Close()
+	p.Variants[0].Chunklist = chunklist
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := new(MasterPlaylist)
+	if err = json.Unmarshal(data, c); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Equal(c) {
+		d, _ := Diff(p, c)
+		t.Errorf("Unmarshaled playlist differs:\n%s", d)
+	}
+	if c.String() != p.String() {
+		t.Errorf("Encoded playlists differ:\n%s\nexpected:\n%s", c.String(), p.String())
+	}
+	if !chunklist.Equal(c.Variants[0].Chunklist) {
+		t.Error("Chunklist was not restored")
+	}
+}
+
+func TestMasterPlaylistJSONSharedAlternatives(t *testing.T) {
+	p := NewMasterPlaylist()
+	err := p.DecodeFrom(strings.NewReader(`#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",LANGUAGE="en",DEFAULT=YES,URI="en.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="Deutsch",LANGUAGE="de",DEFAULT=NO,URI="de.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=1000000,AUDIO="aac"
+low.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=3000000,AUDIO="aac"
+high.m3u8
+`), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := new(MasterPlaylist)
+	if err = json.Unmarshal(data, c); err != nil {
+		t.Fatal(err)
+	}
+	if n, m := countAlternatives(p), countAlternatives(c); n != m {
+		t.Errorf("Shared alternatives were not restored: %d distinct, expected %d", m, n)
+	}
+	if c.Variants[0].Alternatives[0] != c.Variants[1].Alternatives[0] {
+		t.Error("Alternatives of the same group must be shared between variants")
+	}
+	if c.String() != p.String() {
+		t.Errorf("Encoded playlists differ:\n%s\nexpected:\n%s", c.String(), p.String())
+	}
+	data2, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data2) != string(data) {
+		t.Errorf("JSON round-trip is not identity:\n%s\nexpected:\n%s", data2, data)
+	}
+}
+
+// countAlternatives returns the number of distinct alternatives of
+// the playlist.
+func countAlternatives(p *MasterPlaylist) int {
+	seen := make(map[*Alternative]bool)
+	for _, v := range p.Variants {
+		for _, alt := range v.Alternatives {
+			seen[alt] = true
+		}
+	}
+	return len(seen)
+}
+
+func TestMediaPlaylistJSONResetsReceiver(t *testing.T) {
+	p, _ := NewMediaPlaylist(0, 2)
+	_ = p.Append("test0.ts", 5, "")
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, _ := NewMediaPlaylist(0, 2)
+	c.SetAutoProgramDateTime(&AutoProgramDateTime{Start: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)})
+	c.SetTargetDurationRounding(TargetDurationRound)
+	if err = c.SetKeyRotation(&KeyRotation{Every: 1, Keys: func(seqId uint64) ([]*Key, error) {
+		return []*Key{{Method: "AES-128", URI: fmt.Sprintf("key%d.bin", seqId)}}, nil
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(data, c); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Append("test1.ts", 5.6, ""); err != nil {
+		t.Fatal(err)
+	}
+	seg := c.Segments[c.last()]
+	if seg.Key != nil || !seg.ProgramDateTime.IsZero() {
+		t.Errorf("State of the receiver survived unmarshaling: key %v, PDT %v", seg.Key, seg.ProgramDateTime)
+	}
+	if c.TargetDuration != 6 {
+		t.Errorf("Target duration must be rounded up, got %v", c.TargetDuration)
+	}
+}
@@ -0,0 +1,99 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines helpers for encryption keys of media playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// Encryption methods of EXT-X-KEY.
+const (
+	KeyMethodNone      = "NONE"
+	KeyMethodAES128    = "AES-128"
+	KeyMethodSampleAES = "SAMPLE-AES"
+)
+
+// ErrInvalidIV is returned for IV which is not a hexadecimal sequence
+// of 128 bits with 0x prefix.
+var ErrInvalidIV = errors.New("IV must be a 128-bit hexadecimal sequence with 0x prefix")
+
+// NewAES128Key returns the key for METHOD=AES-128 encryption of whole
+// segments. Empty IV means the implicit IV derived from the media
+// sequence number of each segment.
+func NewAES128Key(uri, iv string) (*Key, error) {
+	return newKey(KeyMethodAES128, uri, iv, "", "")
+}
+
+// NewSampleAESKey returns the key for METHOD=SAMPLE-AES encryption of
+// media samples. Keyformat and keyformatversions are optional, empty
+// IV means the implicit IV.
+func NewSampleAESKey(uri, iv, keyformat, keyformatversions string) (*Key, error) {
+	return newKey(KeyMethodSampleAES, uri, iv, keyformat, keyformatversions)
+}
+
+func newKey(method, uri, iv, keyformat, keyformatversions string) (*Key, error) {
+	if uri == "" {
+		return nil, errors.New("key URI is required for METHOD=" + method)
+	}
+	if iv != "" {
+		if err := ValidateIV(iv); err != nil {
+			return nil, err
+		}
+	}
+	return &Key{method, uri, iv, keyformat, keyformatversions}, nil
+}
+
+// ValidateIV checks that the value of IV attribute is a hexadecimal
+// sequence of 128 bits with 0x or 0X prefix.
+func ValidateIV(iv string) error {
+	_, err := ParseIV(iv)
+	return err
+}
+
+// ParseIV decodes the value of IV attribute.
+func ParseIV(iv string) ([16]byte, error) {
+	var out [16]byte
+	if !strings.HasPrefix(iv, "0x") && !strings.HasPrefix(iv, "0X") || len(iv) != 2+2*len(out) {
+		return out, ErrInvalidIV
+	}
+	if _, err := hex.Decode(out[:], []byte(iv[2:])); err != nil {
+		return out, ErrInvalidIV
+	}
+	return out, nil
+}
+
+// FormatIV encodes IV as the value of IV attribute.
+func FormatIV(iv [16]byte) string {
+	return "0x" + strings.ToUpper(hex.EncodeToString(iv[:]))
+}
+
+// ImplicitIV returns IV used for the segment when the key has no IV
+// attribute: the media sequence number of the segment as big-endian
+// 128-bit integer.
+func ImplicitIV(seqId uint64) [16]byte {
+	var iv [16]byte
+	binary.BigEndian.PutUint64(iv[8:], seqId)
+	return iv
+}
+
+// IVFor returns IV of the key for the segment with the sequence
+// number. It is the IV attribute if the key has it and the implicit
+// IV otherwise.
+func (k *Key) IVFor(seqId uint64) ([16]byte, error) {
+	if k.IV == "" {
+		return ImplicitIV(seqId), nil
+	}
+	return ParseIV(k.IV)
+}
@@ -0,0 +1,150 @@
+/*
+ Encryption key helpers tests.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNewKeys(t *testing.T) {
+	key, err := NewAES128Key("https://example.com/key", "0x000102030405060708090A0B0C0D0E0F")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Method != KeyMethodAES128 || key.URI != "https://example.com/key" {
+		t.Errorf("Unexpected key: %+v", key)
+	}
+	key, err = NewSampleAESKey("skd://key", "", "com.apple.streamingkeydelivery", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Method != KeyMethodSampleAES || key.IV != "" || key.Keyformat != "com.apple.streamingkeydelivery" {
+		t.Errorf("Unexpected key: %+v", key)
+	}
+	if _, err = NewAES128Key("", ""); err == nil {
+		t.Error("Expected error for key without URI")
+	}
+	if _, err = NewAES128Key("key", "0x0102"); err != ErrInvalidIV {
+		t.Errorf("Expected invalid IV error, got %v", err)
+	}
+}
+
+func TestValidateIV(t *testing.T) {
+	for iv, valid := range map[string]bool{
+		"0x000102030405060708090a0b0c0d0e0f":   true,
+		"0X000102030405060708090A0B0C0D0E0F":   true,
+		"000102030405060708090A0B0C0D0E0F":     false,
+		"0x000102030405060708090A0B0C0D0E":     false,
+		"0x000102030405060708090A0B0C0D0E0F00": false,
+		"0x000102030405060708090A0B0C0D0E0G":   false,
+		"":                                     false,
+	} {
+		if err := ValidateIV(iv); (err == nil) != valid {
+			t.Errorf("IV %q: expected valid %v, got error %v", iv, valid, err)
+		}
+	}
+}
+
+func TestImplicitIV(t *testing.T) {
+	iv := ImplicitIV(0x0102)
+	if s := FormatIV(iv); s != "0x00000000000000000000000000000102" {
+		t.Errorf("Unexpected implicit IV: %s", s)
+	}
+	key := &Key{Method: KeyMethodAES128, URI: "key"}
+	if got, _ := key.IVFor(0x0102); got != iv {
+		t.Errorf("Expected implicit IV %x, got %x", iv, got)
+	}
+	key.IV = "0x000102030405060708090A0B0C0D0E0F"
+	got, err := key.IVFor(0x0102)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if FormatIV(got) != key.IV {
+		t.Errorf("Expected IV %s, got %s", key.IV, FormatIV(got))
+	}
+}
+
+func TestMediaKeyRotation(t *testing.T) {
+	tests := []struct {
+		rotation KeyRotation
+		expected []string // key URIs of segments, empty for segments without EXT-X-KEY
+	}{
+		{KeyRotation{Every: 2}, []string{"key0", "", "key2", "", "key4", ""}},
+		{KeyRotation{Interval: 10}, []string{"key0", "", "", "key3", "", ""}},
+		{KeyRotation{Every: 2, Interval: 8}, []string{"key0", "", "key2", "", "key4", ""}},
+	}
+	for _, test := range tests {
+		p, e := NewMediaPlaylist(6, 6)
+		if e != nil {
+			t.Fatalf("Create media playlist failed: %s", e)
+		}
+		rotation := test.rotation
+		rotation.Keys = func(seqId uint64) ([]*Key, error) {
+			key, err := NewAES128Key(fmt.Sprintf("key%d", seqId), "")
+			return []*Key{key}, err
+		}
+		if e = p.SetKeyRotation(&rotation); e != nil {
+			t.Fatal(e)
+		}
+		for i := 0; i < 6; i++ {
+			if e = p.Append(fmt.Sprintf("test%d.ts", i), 4, ""); e != nil {
+				t.Fatal(e)
+			}
+		}
+		for i, uri := range test.expected {
+			seg := p.Segments[i]
+			if uri == "" && seg.Key != nil || uri != "" && (seg.Key == nil || seg.Key.URI != uri) {
+				t.Errorf("%+v: unexpected key of segment %d: %+v", test.rotation, i, seg.Key)
+			}
+		}
+	}
+}
+
+func TestMediaKeyRotationWithExplicitKey(t *testing.T) {
+	p, e := NewMediaPlaylist(6, 6)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	e = p.SetKeyRotation(&KeyRotation{Every: 3, Keys: func(seqId uint64) ([]*Key, error) {
+		return []*Key{{Method: KeyMethodAES128, URI: fmt.Sprintf("key%d", seqId)}}, nil
+	}})
+	if e != nil {
+		t.Fatal(e)
+	}
+	for i := 0; i < 6; i++ {
+		_ = p.Append(fmt.Sprintf("test%d.ts", i), 4, "")
+		if i == 1 {
+			_ = p.SetKey(KeyMethodAES128, "explicit", "", "", "")
+		}
+	}
+	for i, uri := range []string{"key0", "explicit", "", "", "key4", ""} {
+		seg := p.Segments[i]
+		if uri == "" && seg.Key != nil || uri != "" && (seg.Key == nil || seg.Key.URI != uri) {
+			t.Errorf("Unexpected key of segment %d: %+v", i, seg.Key)
+		}
+	}
+	if e = p.SetKeyRotation(&KeyRotation{Every: 3}); e == nil {
+		t.Error("Expected error for rotation without keys")
+	}
+}
+
+func TestMediaKeyRotationWithNilKey(t *testing.T) {
+	p, _ := NewMediaPlaylist(0, 2)
+	err := p.SetKeyRotation(&KeyRotation{Every: 1, Keys: func(seqId uint64) ([]*Key, error) {
+		return []*Key{{Method: KeyMethodAES128, URI: "key.bin"}, nil}, nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Append("test0.ts", 5, ""); err == nil {
+		t.Error("Expected error for nil key of the set")
+	}
+}
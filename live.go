@@ -0,0 +1,147 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines concurrency-safe wrapper for live media playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"bytes"
+	"sync"
+)
+
+// LiveMediaPlaylist wraps a media playlist for concurrent use. One
+// goroutine may append and slide segments while many others encode
+// the playlist. Encoded output is kept as an immutable snapshot which
+// is regenerated on the first Encode after any change.
+//
+// MediaPlaylist itself is not safe for concurrent use because Encode
+// caches the output inside the playlist.
+type LiveMediaPlaylist struct {
+	mu       sync.RWMutex
+	p        *MediaPlaylist
+	snapshot []byte // nil when the playlist changed after the last encoding
+}
+
+// NewLiveMediaPlaylist creates a new concurrency-safe media playlist
+// with the sliding window of winsize segments and the capacity of the
+// segments buffer.
+func NewLiveMediaPlaylist(winsize uint, capacity uint) (*LiveMediaPlaylist, error) {
+	p, err := NewMediaPlaylist(winsize, capacity)
+	if err != nil {
+		return nil, err
+	}
+	return &LiveMediaPlaylist{p: p}, nil
+}
+
+// WrapMediaPlaylist makes the existing media playlist safe for
+// concurrent use. The playlist must not be accessed directly after
+// wrapping.
+func WrapMediaPlaylist(p *MediaPlaylist) *LiveMediaPlaylist {
+	return &LiveMediaPlaylist{p: p}
+}
+
+// Append appends a general segment to the playlist.
+func (l *LiveMediaPlaylist) Append(uri string, duration float64, title string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.snapshot = nil
+	return l.p.Append(uri, duration, title)
+}
+
+// AppendSegment appends a MediaSegment to the playlist. The segment
+// must not be changed by the caller after appending.
+func (l *LiveMediaPlaylist) AppendSegment(seg *MediaSegment) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.snapshot = nil
+	return l.p.AppendSegment(seg)
+}
+
+// Slide removes the oldest segment when the window is full and
+// appends a new one.
+func (l *LiveMediaPlaylist) Slide(uri string, duration float64, title string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.snapshot = nil
+	l.p.Slide(uri, duration, title)
+}
+
+// Remove removes the oldest segment of the playlist.
+func (l *LiveMediaPlaylist) Remove() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.snapshot = nil
+	return l.p.Remove()
+}
+
+// Close closes the playlist (adds EXT-X-ENDLIST).
+func (l *LiveMediaPlaylist) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.snapshot = nil
+	l.p.Close()
+}
+
+// Update calls fn with exclusive access to the wrapped playlist. Use
+// it for operations without own wrapper method (SetKey,
+// SetDiscontinuity etc.). The playlist must not be retained by fn.
+func (l *LiveMediaPlaylist) Update(fn func(p *MediaPlaylist) error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.snapshot = nil
+	return fn(l.p)
+}
+
+// Count returns the number of segments in the playlist.
+func (l *LiveMediaPlaylist) Count() uint {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.p.Count()
+}
+
+// Snapshot returns a deep copy of the wrapped playlist. The copy is
+// not shared and may be used without locking.
+func (l *LiveMediaPlaylist) Snapshot() *MediaPlaylist {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.p.Clone()
+}
+
+// Bytes returns the playlist encoded in M3U8 format. Returned slice
+// is shared between callers and must not be modified.
+func (l *LiveMediaPlaylist) Bytes() []byte {
+	l.mu.RLock()
+	snapshot := l.snapshot
+	l.mu.RUnlock()
+	if snapshot != nil {
+		return snapshot
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.snapshot == nil {
+		l.p.ResetCache()
+		out := l.p.Encode().Bytes()
+		l.snapshot = make([]byte, len(out))
+		copy(l.snapshot, out)
+	}
+	return l.snapshot
+}
+
+// Encode returns the playlist encoded in M3U8 format. Each call returns
+// a new buffer so it may be consumed independently by each caller.
+func (l *LiveMediaPlaylist) Encode() *bytes.Buffer {
+	return bytes.NewBuffer(append([]byte(nil), l.Bytes()...))
+}
+
+// String returns the playlist encoded in M3U8 format.
+func (l *LiveMediaPlaylist) String() string {
+	return string(l.Bytes())
+}
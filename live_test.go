@@ -0,0 +1,110 @@
+/*
+ Concurrency-safe live playlist tests.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLiveMediaPlaylistParallelSlideAndEncode(t *testing.T) {
+	l, e := NewLiveMediaPlaylist(5, 10)
+	if e != nil {
+		t.Fatalf("Create live media playlist failed: %s", e)
+	}
+	const segments = 500
+	const readers = 16
+
+	var wg sync.WaitGroup
+	errs := make(chan error, readers)
+	done := make(chan struct{})
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				buf := l.Encode()
+				if !bytes.HasPrefix(buf.Bytes(), []byte("#EXTM3U\n")) {
+					errs <- fmt.Errorf("unexpected output: %q", buf.String())
+					return
+				}
+				// writes to the returned buffer must not affect others
+				buf.WriteString("garbage")
+				if n := strings.Count(l.String(), "#EXTINF:"); n > 5 {
+					errs <- fmt.Errorf("window exceeded: %d segments", n)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < segments; i++ {
+		l.Slide(fmt.Sprintf("test%d.ts", i), 5.0, "")
+		if i%50 == 0 {
+			_ = l.Update(func(p *MediaPlaylist) error {
+				return p.SetDiscontinuity()
+			})
+		}
+	}
+	close(done)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	out := l.String()
+	if strings.Contains(out, "garbage") {
+		t.Error("Snapshot was modified by a reader")
+	}
+	if !strings.Contains(out, fmt.Sprintf("test%d.ts", segments-1)) {
+		t.Errorf("Last segment is absent in the output:\n%s", out)
+	}
+	if s := l.Snapshot(); s.Count() != 5 || s.SeqNo != segments-5 {
+		t.Errorf("Unexpected snapshot state: count %d, SeqNo %d", s.Count(), s.SeqNo)
+	}
+}
+
+func TestLiveMediaPlaylistClose(t *testing.T) {
+	p, _ := NewMediaPlaylist(3, 3)
+	l := WrapMediaPlaylist(p)
+	_ = l.Append("test0.ts", 5.0, "")
+	before := l.String()
+	l.Close()
+	if strings.Contains(before, "#EXT-X-ENDLIST") || !strings.HasSuffix(l.String(), "#EXT-X-ENDLIST\n") {
+		t.Errorf("Unexpected output after close:\n%s", l.String())
+	}
+}
+
+func TestLiveMediaPlaylistEncodeIsNotShared(t *testing.T) {
+	p, _ := NewMediaPlaylist(3, 3)
+	l := WrapMediaPlaylist(p)
+	_ = l.Append("test0.ts", 5.0, "")
+	expected := l.String()
+
+	buf := l.Encode()
+	if _, err := buf.WriteTo(new(bytes.Buffer)); err != nil {
+		t.Fatal(err)
+	}
+	buf.WriteString("CORRUPTED")
+	if l.String() != expected {
+		t.Errorf("Writes to the encoded buffer changed the playlist:\n%s", l.String())
+	}
+	if l.Encode().String() != expected {
+		t.Errorf("Writes to the encoded buffer changed next buffers:\n%s", l.Encode().String())
+	}
+}
@@ -26,6 +26,14 @@ var ErrorNoEXTM3U = errors.New("#EXTM3U absent")
 
 var reKeyValue = regexp.MustCompile(`([a-zA-Z0-9_-]+)=("[^"]+"|[^",]+)`)
 
+// oatclsContAttributes maps upper-cased attributes of EXT-X-CUE-OUT-CONT
+// to their names in OATCLS syntax.
+var oatclsContAttributes = map[string]string{
+	"SCTE35":      "SCTE35",
+	"DURATION":    "Duration",
+	"ELAPSEDTIME": "ElapsedTime",
+}
+
 // TimeParse allows globally apply and/or override Time Parser function.
 // Available variants:
 //   - FullTimeParse - implements full featured ISO/IEC 8601:2004
@@ -399,6 +407,9 @@ func decodeLineOfMasterPlaylist(p *MasterPlaylist, state *decodingState, line st
 			case "CODECS":
 				state.variant.Codecs = v
 			case "RESOLUTION":
+				if _, err = ParseResolution(v); strict && err != nil {
+					return err
+				}
 				state.variant.Resolution = v
 			case "AUDIO":
 				state.variant.Audio = v
@@ -418,12 +429,18 @@ func decodeLineOfMasterPlaylist(p *MasterPlaylist, state *decodingState, line st
 				}
 				state.variant.AverageBandwidth = uint32(val)
 			case "FRAME-RATE":
-				if state.variant.FrameRate, err = strconv.ParseFloat(v, 64); strict && err != nil {
+				if state.variant.FrameRate, err = parseFrameRate(v); strict && err != nil {
 					return err
 				}
 			case "VIDEO-RANGE":
+				if strict && !VideoRange(v).Valid() {
+					return fmt.Errorf("invalid video range %q", v)
+				}
 				state.variant.VideoRange = v
 			case "HDCP-LEVEL":
+				if strict && !HDCPLevel(v).Valid() {
+					return fmt.Errorf("invalid HDCP level %q", v)
+				}
 				state.variant.HDCPLevel = v
 			}
 		}
@@ -456,6 +473,9 @@ func decodeLineOfMasterPlaylist(p *MasterPlaylist, state *decodingState, line st
 			case "CODECS":
 				state.variant.Codecs = v
 			case "RESOLUTION":
+				if _, err = ParseResolution(v); strict && err != nil {
+					return err
+				}
 				state.variant.Resolution = v
 			case "AUDIO":
 				state.variant.Audio = v
@@ -469,8 +489,14 @@ func decodeLineOfMasterPlaylist(p *MasterPlaylist, state *decodingState, line st
 				}
 				state.variant.AverageBandwidth = uint32(val)
 			case "VIDEO-RANGE":
+				if strict && !VideoRange(v).Valid() {
+					return fmt.Errorf("invalid video range %q", v)
+				}
 				state.variant.VideoRange = v
 			case "HDCP-LEVEL":
+				if strict && !HDCPLevel(v).Valid() {
+					return fmt.Errorf("invalid HDCP level %q", v)
+				}
 				state.variant.HDCPLevel = v
 			}
 		}
@@ -573,22 +599,24 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 				return err
 			}
 		}
-		// If EXT-X-KEY appeared before reference to segment (EXTINF) then it linked to this segment
-		if state.tagKey {
+		// EXT-X-KEY is in force for all segments up to the next EXT-X-KEY so
+		// each segment is linked to the key which applies to it
+		if state.xkey != nil {
 			if segment := p.Segments[p.last()]; segment != nil {
-				segment.Key = &Key{state.xkey.Method, state.xkey.URI, state.xkey.IV, state.xkey.Keyformat, state.xkey.Keyformatversions}
+				segment.Key, segment.Keys = state.xkey, state.xkeys
 			}
 			// First EXT-X-KEY may appeared in the header of the playlist and linked to first segment
 			// but for convenient playlist generation it also linked as default playlist key
 			if p.Key == nil {
-				p.Key = state.xkey
+				p.Key, p.Keys = state.xkey, state.xkeys
 			}
 			state.tagKey = false
 		}
-		// If EXT-X-MAP appeared before reference to segment (EXTINF) then it linked to this segment
-		if state.tagMap {
+		// EXT-X-MAP is in force for all segments up to the next EXT-X-MAP so
+		// each segment is linked to the map which applies to it
+		if state.xmap != nil {
 			if segment := p.Segments[p.last()]; segment != nil {
-				segment.Map = &Map{state.xmap.URI, state.xmap.Limit, state.xmap.Offset}
+				segment.Map = state.xmap
 			}
 			// First EXT-X-MAP may appeared in the header of the playlist and linked to first segment
 			// but for convenient playlist generation it also linked as default playlist map
@@ -664,21 +692,30 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 		}
 	case strings.HasPrefix(line, "#EXT-X-KEY:"):
 		state.listType = MEDIA
-		state.xkey = new(Key)
+		key := new(Key)
 		for k, v := range decodeParamsLine(line[11:]) {
 			switch k {
 			case "METHOD":
-				state.xkey.Method = v
+				key.Method = v
 			case "URI":
-				state.xkey.URI = v
+				key.URI = v
 			case "IV":
-				state.xkey.IV = v
+				key.IV = v
 			case "KEYFORMAT":
-				state.xkey.Keyformat = v
+				key.Keyformat = v
 			case "KEYFORMATVERSIONS":
-				state.xkey.Keyformatversions = v
+				key.Keyformatversions = v
 			}
 		}
+		// subsequent EXT-X-KEY tags before the same segment make the key
+		// set for different KEYFORMATs, METHOD=NONE clears the set and
+		// the repeated KEYFORMAT starts the new one
+		if state.tagKey && key.Method != "NONE" && state.xkey.Method != "NONE" &&
+			!containsKeyformat(keySet(state.xkey, state.xkeys), key.Keyformat) {
+			state.xkeys = append(state.xkeys, key)
+		} else {
+			state.xkey, state.xkeys = key, nil
+		}
 		state.tagKey = true
 	case strings.HasPrefix(line, "#EXT-X-MAP:"):
 		state.listType = MEDIA
@@ -740,30 +777,122 @@ func decodeLineOfMediaPlaylist(p *MediaPlaylist, wv *WV, state *decodingState, l
 		state.scte = new(SCTE)
 		state.scte.Syntax = SCTE35_OATCLS
 		state.scte.Cue = line[19:]
-	case state.tagSCTE35 && state.scte.Syntax == SCTE35_OATCLS && strings.HasPrefix(line, "#EXT-X-CUE-OUT:"):
+	case state.tagSCTE35 && state.scte.Syntax == SCTE35_OATCLS && (line == "#EXT-X-CUE-OUT" || strings.HasPrefix(line, "#EXT-X-CUE-OUT:")):
 		// EXT-OATCLS-SCTE35 contains the SCTE35 tag, EXT-X-CUE-OUT contains duration
-		state.scte.Time, _ = strconv.ParseFloat(line[15:], 64)
+		if len(line) > 15 {
+			state.scte.Time, _ = strconv.ParseFloat(line[15:], 64)
+		}
 		state.scte.CueType = SCTE35Cue_Start
+		state.cueOutSyntax = SCTE35_OATCLS
+	case !state.tagSCTE35 && line == "#EXT-X-CUE-OUT":
+		// bare EXT-X-CUE-OUT of Elemental without duration
+		state.tagSCTE35 = true
+		state.scte = new(SCTE)
+		state.scte.Syntax = SCTE35_ELEMENTAL
+		state.scte.CueType = SCTE35Cue_Start
+		state.cueOutSyntax = SCTE35_ELEMENTAL
+	case !state.tagSCTE35 && strings.HasPrefix(line, "#EXT-X-CUE-OUT:"):
+		state.tagSCTE35 = true
+		state.scte = new(SCTE)
+		state.scte.CueType = SCTE35Cue_Start
+		if value := line[15:]; strings.Contains(value, "=") {
+			// EXT-X-CUE-OUT:DURATION=30
+			state.scte.Syntax = SCTE35_ELEMENTAL
+			for attribute, value := range decodeParamsLine(value) {
+				if strings.EqualFold(attribute, "DURATION") {
+					state.scte.Time, _ = strconv.ParseFloat(value, 64)
+				}
+			}
+		} else {
+			// EXT-X-CUE-OUT:30
+			state.scte.Syntax = SCTE35_ANVATO
+			state.scte.Time, _ = strconv.ParseFloat(value, 64)
+		}
+		state.cueOutSyntax = state.scte.Syntax
 	case !state.tagSCTE35 && strings.HasPrefix(line, "#EXT-X-CUE-OUT-CONT:"):
 		state.tagSCTE35 = true
 		state.scte = new(SCTE)
-		state.scte.Syntax = SCTE35_OATCLS
 		state.scte.CueType = SCTE35Cue_Mid
+		if value := line[20:]; !strings.Contains(value, "=") {
+			// EXT-X-CUE-OUT-CONT:10/30
+			state.scte.Syntax = SCTE35_ELEMENTAL
+			times := strings.SplitN(value, "/", 2)
+			state.scte.Elapsed, _ = strconv.ParseFloat(times[0], 64)
+			if len(times) > 1 {
+				state.scte.Time, _ = strconv.ParseFloat(times[1], 64)
+			}
+			break
+		}
+		// attribute names of OATCLS are capitalized, other
+		// capitalization variants come from Anvato and AWS
+		state.scte.Syntax = SCTE35_OATCLS
 		for attribute, value := range decodeParamsLine(line[20:]) {
-			switch attribute {
+			name := strings.ToUpper(attribute)
+			switch name {
 			case "SCTE35":
 				state.scte.Cue = value
-			case "Duration":
+			case "DURATION":
 				state.scte.Time, _ = strconv.ParseFloat(value, 64)
-			case "ElapsedTime":
+			case "ELAPSEDTIME":
 				state.scte.Elapsed, _ = strconv.ParseFloat(value, 64)
 			}
+			if canonical, ok := oatclsContAttributes[name]; ok && canonical != attribute {
+				state.scte.Syntax = SCTE35_ANVATO
+			}
 		}
 	case !state.tagSCTE35 && line == "#EXT-X-CUE-IN":
 		state.tagSCTE35 = true
 		state.scte = new(SCTE)
 		state.scte.Syntax = SCTE35_OATCLS
+		switch state.cueOutSyntax {
+		case SCTE35_ELEMENTAL, SCTE35_ANVATO:
+			state.scte.Syntax = state.cueOutSyntax
+		}
 		state.scte.CueType = SCTE35Cue_End
+	case !state.tagSCTE35 && strings.HasPrefix(line, "#EXT-X-CUE:"):
+		state.tagSCTE35 = true
+		state.scte = new(SCTE)
+		state.scte.Syntax = SCTE35_ADOBE
+		for attribute, value := range decodeParamsLine(line[11:]) {
+			switch attribute {
+			case "TYPE":
+				if value == "SpliceIn" {
+					state.scte.CueType = SCTE35Cue_End
+				}
+			case "ID":
+				state.scte.ID = value
+			case "DURATION":
+				state.scte.Time, _ = strconv.ParseFloat(value, 64)
+			case "CUE":
+				state.scte.Cue = value
+			}
+		}
+	case !state.tagSCTE35 && strings.HasPrefix(line, "#EXT-X-SPLICEPOINT-SCTE35:"):
+		state.tagSCTE35 = true
+		state.scte = new(SCTE)
+		state.scte.Syntax = SCTE35_SPLICEPOINT
+		state.scte.Cue = line[26:]
+		state.scte.CueType = spliceCueType(state.scte.Cue)
+	case !state.tagSCTE35 && strings.HasPrefix(line, "#EXT-X-DATERANGE:"):
+		params := decodeParamsLine(line[17:])
+		if params["SCTE35-OUT"] == "" && params["SCTE35-IN"] == "" {
+			// other date ranges are not supported
+			break
+		}
+		state.tagSCTE35 = true
+		state.scte = new(SCTE)
+		state.scte.Syntax = SCTE35_DATERANGE
+		state.scte.ID = params["ID"]
+		if cue, ok := params["SCTE35-OUT"]; ok {
+			state.scte.Cue = cue
+			state.scte.Time, _ = strconv.ParseFloat(params["PLANNED-DURATION"], 64)
+		} else {
+			state.scte.Cue = params["SCTE35-IN"]
+			state.scte.CueType = SCTE35Cue_End
+		}
+		if duration, ok := params["DURATION"]; ok {
+			state.scte.Time, _ = strconv.ParseFloat(duration, 64)
+		}
 	case !state.tagDiscontinuity && strings.HasPrefix(line, "#EXT-X-DISCONTINUITY"):
 		state.tagDiscontinuity = true
 		state.listType = MEDIA
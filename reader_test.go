@@ -16,6 +16,7 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -644,6 +645,173 @@ path
 	}
 }
 
+// Check that each segment is linked to the key and the map in force
+// for it, not only the segment after the tag.
+func TestDecodeMediaPlaylistEffectiveKeyAndMap(t *testing.T) {
+	playlist := `#EXTM3U
+#EXT-X-VERSION:5
+#EXT-X-TARGETDURATION:10
+#EXT-X-KEY:METHOD=AES-128,URI="key1.bin"
+#EXT-X-MAP:URI="init1.mp4"
+#EXTINF:10.000,
+test0.ts
+#EXTINF:10.000,
+test1.ts
+#EXT-X-KEY:METHOD=AES-128,URI="key2.bin"
+#EXTINF:10.000,
+test2.ts
+#EXTINF:10.000,
+test3.ts
+#EXT-X-KEY:METHOD=NONE
+#EXTINF:10.000,
+test4.ts
+`
+	p, err := NewMediaPlaylist(5, 5)
+	if err != nil {
+		t.Fatalf("Create media playlist failed: %s", err)
+	}
+	if err = p.DecodeFrom(bytes.NewBufferString(playlist), true); err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range []string{"key1.bin", "key1.bin", "key2.bin", "key2.bin", ""} {
+		seg := p.Segments[i]
+		if seg.Key == nil || seg.Key.URI != expected {
+			t.Errorf("Segment %d: unexpected key %+v", i, seg.Key)
+		}
+		if seg.Map == nil || seg.Map.URI != "init1.mp4" {
+			t.Errorf("Segment %d: unexpected map %+v", i, seg.Map)
+		}
+	}
+	if p.Segments[4].Key.Method != "NONE" {
+		t.Errorf("Unexpected key method of the last segment: %s", p.Segments[4].Key.Method)
+	}
+	// keys are emitted only where they change
+	if out := p.String(); strings.Count(out, "#EXT-X-KEY:") != 3 || strings.Count(out, "#EXT-X-MAP:") != 1 {
+		t.Errorf("Unexpected encoded playlist:\n%s", out)
+	}
+}
+
+func TestDecodeMediaPlaylistWithKeySets(t *testing.T) {
+	playlist := `#EXTM3U
+#EXT-X-VERSION:5
+#EXT-X-TARGETDURATION:10
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="skd://key1",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="data:text/plain;base64,AAAA",KEYFORMAT="urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed",KEYFORMATVERSIONS="1"
+#EXTINF:10.000,
+test0.ts
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="data:text/plain;base64,AAAA",KEYFORMAT="urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed",KEYFORMATVERSIONS="1"
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="skd://key1",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"
+#EXTINF:10.000,
+test1.ts
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="skd://key2",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="data:text/plain;base64,BBBB",KEYFORMAT="urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed",KEYFORMATVERSIONS="1"
+#EXTINF:10.000,
+test2.ts
+#EXT-X-KEY:METHOD=NONE
+#EXTINF:10.000,
+test3.ts
+`
+	p, err := NewMediaPlaylist(4, 4)
+	if err != nil {
+		t.Fatalf("Create media playlist failed: %s", err)
+	}
+	if err = p.DecodeFrom(bytes.NewBufferString(playlist), true); err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range []int{2, 2, 2, 0} {
+		seg := p.Segments[i]
+		if n := len(keySet(seg.Key, seg.Keys)); n != expected {
+			t.Errorf("Segment %d: expected %d keys in the set, got %d", i, expected, n)
+		}
+	}
+	if p.Key == nil || len(p.Keys) != 1 {
+		t.Errorf("Expected the default key set of 2 keys, got %+v %+v", p.Key, p.Keys)
+	}
+	if p.Segments[2].Keys[0].URI != "data:text/plain;base64,BBBB" {
+		t.Errorf("Unexpected second key of segment 2: %+v", p.Segments[2].Keys[0])
+	}
+	// the reordered set of the second segment is the same set
+	out := p.String()
+	if n := strings.Count(out, "#EXT-X-KEY:"); n != 5 {
+		t.Errorf("Expected 5 keys in the encoded playlist, got %d:\n%s", n, out)
+	}
+	if !strings.Contains(out, "test0.ts\n#EXTINF:10.000,\ntest1.ts\n#EXT-X-KEY:METHOD=SAMPLE-AES,URI=\"skd://key2\"") {
+		t.Errorf("Unexpected encoded playlist:\n%s", out)
+	}
+}
+
+func TestDecodeMediaPlaylistWithRepeatedKeyformat(t *testing.T) {
+	playlist := `#EXTM3U
+#EXT-X-VERSION:5
+#EXT-X-TARGETDURATION:10
+#EXT-X-KEY:METHOD=AES-128,URI="key1"
+#EXT-X-KEY:METHOD=AES-128,URI="key2",KEYFORMAT="identity"
+#EXTINF:10.000,
+test0.ts
+`
+	p, err := NewMediaPlaylist(1, 1)
+	if err != nil {
+		t.Fatalf("Create media playlist failed: %s", err)
+	}
+	if err = p.DecodeFrom(bytes.NewBufferString(playlist), true); err != nil {
+		t.Fatal(err)
+	}
+	// the repeated KEYFORMAT replaces the set
+	seg := p.Segments[0]
+	if seg.Key == nil || seg.Key.URI != "key2" || len(seg.Keys) != 0 {
+		t.Errorf("Expected the single key2, got %+v %+v", seg.Key, seg.Keys)
+	}
+}
+
+func TestMediaPlaylistWithKeySetsRoundTrip(t *testing.T) {
+	playlist := `#EXTM3U
+#EXT-X-VERSION:5
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-TARGETDURATION:10
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="skd://key1",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="data:text/plain;base64,AAAA",KEYFORMAT="urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed",KEYFORMATVERSIONS="1"
+#EXTINF:10.000,
+test0.ts
+#EXT-X-KEY:METHOD=NONE
+#EXTINF:10.000,
+test1.ts
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="skd://key2",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="data:text/plain;base64,BBBB",KEYFORMAT="urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed",KEYFORMATVERSIONS="1"
+#EXTINF:10.000,
+test2.ts
+#EXTINF:10.000,
+test3.ts
+`
+	p, err := NewMediaPlaylist(4, 4)
+	if err != nil {
+		t.Fatalf("Create media playlist failed: %s", err)
+	}
+	if err = p.DecodeFrom(bytes.NewBufferString(playlist), true); err != nil {
+		t.Fatal(err)
+	}
+	// METHOD=NONE of the head and then the second set go to the header
+	for i, expected := range []int{3, 2} {
+		if err = p.Remove(); err != nil {
+			t.Fatal(err)
+		}
+		out := p.String()
+		if n := strings.Count(out, "#EXT-X-KEY:"); n != expected {
+			t.Errorf("Expected %d keys in the encoded playlist, got %d:\n%s", expected, n, out)
+		}
+		c, err := NewMediaPlaylist(4, 4)
+		if err != nil {
+			t.Fatalf("Create media playlist failed: %s", err)
+		}
+		if err = c.DecodeFrom(bytes.NewBufferString(out), true); err != nil {
+			t.Fatal(err)
+		}
+		if !p.Equal(c) {
+			d, _ := Diff(p, c)
+			t.Errorf("Decoded playlist differs after %d removals:\n%s", i+1, d)
+		}
+	}
+}
+
 func TestDecodeMediaPlaylistWithWidevine(t *testing.T) {
 	f, err := os.Open("sample-playlists/widevine-bitrate.m3u8")
 	if err != nil {
@@ -819,6 +987,94 @@ func TestMediaPlaylistWithOATCLSSCTE35Tag(t *testing.T) {
 	}
 }
 
+func TestMediaPlaylistWithSCTE35Dialects(t *testing.T) {
+	const cue = "/DAlAAAAAAAAAP/wFAUAAAABf+/+ANgNkv4AFJlwAAEBAQAA5xULLA=="
+	const hexCue = "0xFC302500000000000000FFF01405000000017FEFFE00D80D92FE00149970000101010000E7150B2C"
+	tests := []struct {
+		file   string
+		expect map[int]*SCTE
+	}{
+		{"media-playlist-with-elemental-scte35.m3u8", map[int]*SCTE{
+			0: {Syntax: SCTE35_ELEMENTAL, CueType: SCTE35Cue_Start, Time: 15},
+			1: {Syntax: SCTE35_ELEMENTAL, CueType: SCTE35Cue_Mid, Time: 15, Elapsed: 8.844},
+			2: {Syntax: SCTE35_ELEMENTAL, CueType: SCTE35Cue_End},
+		}},
+		{"media-playlist-with-anvato-scte35.m3u8", map[int]*SCTE{
+			0: {Syntax: SCTE35_ANVATO, CueType: SCTE35Cue_Start, Time: 15},
+			1: {Syntax: SCTE35_ANVATO, CueType: SCTE35Cue_Mid, Cue: cue, Time: 15, Elapsed: 8.844},
+			2: {Syntax: SCTE35_ANVATO, CueType: SCTE35Cue_End},
+		}},
+		{"media-playlist-with-adobe-scte35.m3u8", map[int]*SCTE{
+			0: {Syntax: SCTE35_ADOBE, CueType: SCTE35Cue_Start, Cue: cue, ID: "1", Time: 15},
+			2: {Syntax: SCTE35_ADOBE, CueType: SCTE35Cue_End, ID: "1"},
+			3: {Syntax: SCTE35_SPLICEPOINT, CueType: SCTE35Cue_Start, Cue: cue},
+		}},
+		{"media-playlist-with-daterange-scte35.m3u8", map[int]*SCTE{
+			1: {Syntax: SCTE35_DATERANGE, CueType: SCTE35Cue_Start, Cue: hexCue, ID: "splice-1", Time: 15},
+			3: {Syntax: SCTE35_DATERANGE, CueType: SCTE35Cue_End, Cue: hexCue, ID: "splice-1", Time: 15},
+		}},
+	}
+	for _, test := range tests {
+		data, err := os.ReadFile("sample-playlists/" + test.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, _, err := DecodeFrom(bytes.NewReader(data), true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pp := p.(*MediaPlaylist)
+		for i := 0; i < int(pp.Count()); i++ {
+			if !reflect.DeepEqual(pp.Segments[i].SCTE, test.expect[i]) {
+				t.Errorf("%s segment %v\ngot: %#v\nexp: %#v", test.file, i, pp.Segments[i].SCTE, test.expect[i])
+			}
+		}
+		// cue tags must be encoded as they were
+		out := pp.String()
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.Contains(line, "CUE") || strings.Contains(line, "SCTE35") {
+				if !strings.Contains(out, line+"\n") {
+					t.Errorf("%s: tag %s is not encoded:\n%s", test.file, line, out)
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeMediaPlaylistWithBareCueOut(t *testing.T) {
+	const playlist = `#EXTM3U
+#EXT-X-TARGETDURATION:10
+#EXT-X-VERSION:3
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-CUE-OUT
+#EXTINF:10.000,
+media0.ts
+#EXT-X-CUE-IN
+#EXTINF:10.000,
+media1.ts
+`
+	p, listType, err := DecodeFrom(strings.NewReader(playlist), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listType != MEDIA {
+		t.Fatal("Sample not recognized as media playlist.")
+	}
+	pp := p.(*MediaPlaylist)
+	expected := []*SCTE{
+		{Syntax: SCTE35_ELEMENTAL, CueType: SCTE35Cue_Start},
+		{Syntax: SCTE35_ELEMENTAL, CueType: SCTE35Cue_End},
+	}
+	for i, scte := range expected {
+		if !reflect.DeepEqual(pp.Segments[i].SCTE, scte) {
+			t.Errorf("segment %d\ngot: %#v\nexp: %#v", i, pp.Segments[i].SCTE, scte)
+		}
+	}
+	if !strings.Contains(pp.String(), "#EXT-X-CUE-OUT\n#EXTINF:10.000,\nmedia0.ts\n") {
+		t.Errorf("Bare cue-out is not encoded as it was:\n%s", pp)
+	}
+}
+
 func TestDecodeMediaPlaylistWithDiscontinuitySeq(t *testing.T) {
 	f, err := os.Open("sample-playlists/media-playlist-with-discontinuity-seq.m3u8")
 	if err != nil {
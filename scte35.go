@@ -0,0 +1,713 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines decoding and encoding of SCTE-35 splice_info_section
+ carried by the cue tags.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrSpliceInfoCRC declares the error returned when CRC_32 of the
+	// splice_info_section doesn't match its content.
+	ErrSpliceInfoCRC = errors.New("splice info CRC mismatch")
+	// ErrSpliceInfoEncrypted declares the error returned for the
+	// splice_info_section with encrypted splice command.
+	ErrSpliceInfoEncrypted = errors.New("encrypted splice info is not supported")
+
+	errSpliceInfoShort = errors.New("splice info is too short")
+)
+
+const (
+	spliceInfoTableID = 0xFC
+	// CUEIIdentifier is the identifier of splice descriptors defined
+	// by SCTE-35 ("CUEI").
+	CUEIIdentifier = 0x43554549
+	// PTSClock is the frequency of PTS and duration values in SCTE-35.
+	PTSClock = 90000
+)
+
+// SpliceCommandType is the splice_command_type of SCTE-35.
+type SpliceCommandType uint8
+
+const (
+	SpliceNullCommand           SpliceCommandType = 0x00
+	SpliceScheduleCommand       SpliceCommandType = 0x04
+	SpliceInsertCommand         SpliceCommandType = 0x05
+	TimeSignalCommand           SpliceCommandType = 0x06
+	BandwidthReservationCommand SpliceCommandType = 0x07
+	PrivateCommand              SpliceCommandType = 0xFF
+)
+
+// Tags of splice descriptors.
+const (
+	AvailDescriptorTag        = 0x00
+	DTMFDescriptorTag         = 0x01
+	SegmentationDescriptorTag = 0x02
+	TimeDescriptorTag         = 0x03
+	AudioDescriptorTag        = 0x04
+)
+
+// Common values of segmentation_type_id.
+const (
+	SegmentationProgramStart                       = 0x10
+	SegmentationProgramEnd                         = 0x11
+	SegmentationChapterStart                       = 0x20
+	SegmentationChapterEnd                         = 0x21
+	SegmentationBreakStart                         = 0x22
+	SegmentationBreakEnd                           = 0x23
+	SegmentationProviderAdStart                    = 0x30
+	SegmentationProviderAdEnd                      = 0x31
+	SegmentationDistributorAdStart                 = 0x32
+	SegmentationDistributorAdEnd                   = 0x33
+	SegmentationProviderPlacementOpportunity       = 0x34
+	SegmentationProviderPlacementOpportunityEnd    = 0x35
+	SegmentationDistributorPlacementOpportunity    = 0x36
+	SegmentationDistributorPlacementOpportunityEnd = 0x37
+)
+
+// SpliceInfo represents SCTE-35 splice_info_section. Only one of
+// SpliceInsert, TimeSignal or RawCommand is set according to
+// CommandType. PTS values are in 90 kHz ticks.
+//
+// For a constructed section SAPType should be 3 (not specified) and
+// Tier should be 0xFFF (all tiers) unless other values are required.
+type SpliceInfo struct {
+	SAPType             uint8
+	ProtocolVersion     uint8
+	EncryptedPacket     bool
+	EncryptionAlgorithm uint8
+	PTSAdjustment       uint64
+	CWIndex             uint8
+	Tier                uint16
+	CommandType         SpliceCommandType
+	SpliceInsert        *SpliceInsert
+	TimeSignal          *TimeSignal
+	RawCommand          []byte // splice_null, splice_schedule, bandwidth_reservation and private commands
+	Descriptors         []SpliceDescriptor
+}
+
+// SpliceInsert represents splice_insert() command.
+type SpliceInsert struct {
+	EventID         uint32
+	EventCancel     bool
+	OutOfNetwork    bool
+	ProgramSplice   bool
+	SpliceImmediate bool
+	PTSTime         *uint64 // splice time of the program, nil if not specified
+	Components      []SpliceComponent
+	BreakDuration   *BreakDuration
+	UniqueProgramID uint16
+	AvailNum        uint8
+	AvailsExpected  uint8
+}
+
+// SpliceComponent represents the component of splice_insert() used
+// when the program is not spliced as a whole.
+type SpliceComponent struct {
+	Tag     uint8
+	PTSTime *uint64
+}
+
+// BreakDuration represents break_duration() of splice_insert().
+type BreakDuration struct {
+	AutoReturn bool
+	Duration   uint64
+}
+
+// Seconds returns the break duration in seconds.
+func (b *BreakDuration) Seconds() float64 {
+	return float64(b.Duration) / PTSClock
+}
+
+// TimeSignal represents time_signal() command.
+type TimeSignal struct {
+	PTSTime *uint64 // nil if time is not specified
+}
+
+// SpliceDescriptor represents a splice descriptor. Segmentation is set
+// for segmentation_descriptor() with CUEI identifier, Data keeps the
+// bytes after the identifier for other descriptors.
+type SpliceDescriptor struct {
+	Tag          uint8
+	Identifier   uint32
+	Segmentation *SegmentationDescriptor
+	Data         []byte
+}
+
+// SegmentationDescriptor represents segmentation_descriptor().
+type SegmentationDescriptor struct {
+	EventID               uint32
+	EventCancel           bool
+	ProgramSegmentation   bool
+	DeliveryNotRestricted bool
+	WebDeliveryAllowed    bool
+	NoRegionalBlackout    bool
+	ArchiveAllowed        bool
+	DeviceRestrictions    uint8
+	Components            []SegmentationComponent
+	Duration              *uint64 // segmentation_duration, nil if not present
+	UPIDType              uint8
+	UPID                  []byte
+	TypeID                uint8
+	SegmentNum            uint8
+	SegmentsExpected      uint8
+	SubSegments           *SubSegments // present for placement opportunities only
+}
+
+// SegmentationComponent represents the component of
+// segmentation_descriptor() used when the program is not segmented as
+// a whole.
+type SegmentationComponent struct {
+	Tag       uint8
+	PTSOffset uint64
+}
+
+// SubSegments represents sub_segment_num and sub_segments_expected of
+// segmentation_descriptor().
+type SubSegments struct {
+	Num      uint8
+	Expected uint8
+}
+
+// DurationSeconds returns the segmentation duration in seconds or zero
+// if it is not present.
+func (d *SegmentationDescriptor) DurationSeconds() float64 {
+	if d.Duration == nil {
+		return 0
+	}
+	return float64(*d.Duration) / PTSClock
+}
+
+// SpliceInfo decodes the cue of the SCTE tag.
+func (s *SCTE) SpliceInfo() (*SpliceInfo, error) {
+	return ParseSpliceInfo(s.Cue)
+}
+
+// ParseSpliceInfo decodes splice_info_section from the cue string in
+// base64 or hex (with 0x prefix) encoding and verifies its CRC.
+func ParseSpliceInfo(cue string) (*SpliceInfo, error) {
+	var (
+		data []byte
+		err  error
+	)
+	cue = strings.TrimSpace(cue)
+	if strings.HasPrefix(cue, "0x") || strings.HasPrefix(cue, "0X") {
+		data, err = hex.DecodeString(cue[2:])
+	} else {
+		data, err = base64.StdEncoding.DecodeString(cue)
+		if err != nil {
+			data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(cue, "="))
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid cue encoding: %s", err)
+	}
+	info := new(SpliceInfo)
+	if err = info.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Cue returns the splice_info_section encoded in base64 as used by
+// the cue tags.
+func (s *SpliceInfo) Cue() (string, error) {
+	data, err := s.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// PTS returns the splice time of the program with PTS adjustment
+// applied. It returns false when the command has no splice time.
+func (s *SpliceInfo) PTS() (uint64, bool) {
+	var pts *uint64
+	switch {
+	case s.SpliceInsert != nil:
+		pts = s.SpliceInsert.PTSTime
+	case s.TimeSignal != nil:
+		pts = s.TimeSignal.PTSTime
+	}
+	if pts == nil {
+		return 0, false
+	}
+	return (*pts + s.PTSAdjustment) & (1<<33 - 1), true
+}
+
+// IsCueIn reports whether the section returns to the network: the
+// splice_insert into the network or the end of a segmentation.
+func (s *SpliceInfo) IsCueIn() bool {
+	if s.SpliceInsert != nil {
+		return !s.SpliceInsert.EventCancel && !s.SpliceInsert.OutOfNetwork
+	}
+	for _, d := range s.Descriptors {
+		// end types of segmentation are odd numbers
+		if d.Segmentation != nil && !d.Segmentation.EventCancel && d.Segmentation.TypeID >= SegmentationProgramStart {
+			return d.Segmentation.TypeID%2 == 1
+		}
+	}
+	return false
+}
+
+// spliceCueType detects the cue type for the tags which carry the cue
+// only. Cues which can't be decoded are considered as cue out.
+func spliceCueType(cue string) SCTE35CueType {
+	if info, err := ParseSpliceInfo(cue); err == nil && info.IsCueIn() {
+		return SCTE35Cue_End
+	}
+	return SCTE35Cue_Start
+}
+
+// UnmarshalBinary decodes splice_info_section and verifies its CRC.
+func (s *SpliceInfo) UnmarshalBinary(data []byte) error {
+	if len(data) < 3 {
+		return errSpliceInfoShort
+	}
+	if data[0] != spliceInfoTableID {
+		return fmt.Errorf("invalid splice info table id: %#x", data[0])
+	}
+	length := 3 + int(binary.BigEndian.Uint16(data[1:3])&0xFFF)
+	if len(data) < length || length < 3+11+2+4 {
+		return errSpliceInfoShort
+	}
+	data = data[:length]
+	if crc32MPEG(data[:length-4]) != binary.BigEndian.Uint32(data[length-4:]) {
+		return ErrSpliceInfoCRC
+	}
+
+	r := &bitReader{data: data[:length-4]}
+	*s = SpliceInfo{}
+	r.skip(8 + 2)
+	s.SAPType = uint8(r.read(2))
+	r.skip(12)
+	s.ProtocolVersion = uint8(r.read(8))
+	s.EncryptedPacket = r.flag()
+	s.EncryptionAlgorithm = uint8(r.read(6))
+	s.PTSAdjustment = r.read(33)
+	s.CWIndex = uint8(r.read(8))
+	s.Tier = uint16(r.read(12))
+	cmdLength := int(r.read(12))
+	s.CommandType = SpliceCommandType(r.read(8))
+	if r.err != nil {
+		return r.err
+	}
+	if s.EncryptedPacket {
+		return ErrSpliceInfoEncrypted
+	}
+
+	start := r.pos / 8
+	switch s.CommandType {
+	case SpliceInsertCommand:
+		s.SpliceInsert = readSpliceInsert(r)
+	case TimeSignalCommand:
+		s.TimeSignal = &TimeSignal{PTSTime: readSpliceTime(r)}
+	default:
+		// legacy value 0xFFF means the length is not specified
+		if cmdLength == 0xFFF {
+			return fmt.Errorf("unknown length of splice command %#x", uint8(s.CommandType))
+		}
+		s.RawCommand = r.bytes(cmdLength)
+	}
+	if r.err != nil {
+		return r.err
+	}
+	if cmdLength != 0xFFF && r.pos/8-start != cmdLength {
+		return fmt.Errorf("invalid splice command length: %d", cmdLength)
+	}
+
+	loopLength := int(r.read(16))
+	end := r.pos/8 + loopLength
+	for r.err == nil && r.pos/8 < end {
+		var d SpliceDescriptor
+		d.Tag = uint8(r.read(8))
+		body := r.bytes(int(r.read(8)))
+		if r.err != nil || len(body) < 4 {
+			return errSpliceInfoShort
+		}
+		d.Identifier = binary.BigEndian.Uint32(body)
+		if d.Tag == SegmentationDescriptorTag && d.Identifier == CUEIIdentifier {
+			br := &bitReader{data: body[4:]}
+			if d.Segmentation = readSegmentationDescriptor(br); br.err != nil {
+				return br.err
+			}
+		} else {
+			d.Data = append([]byte(nil), body[4:]...)
+		}
+		s.Descriptors = append(s.Descriptors, d)
+	}
+	if r.err != nil {
+		return r.err
+	}
+	if r.pos/8 != end {
+		return errors.New("invalid splice descriptors length")
+	}
+	return nil
+}
+
+// MarshalBinary encodes splice_info_section with CRC_32.
+func (s *SpliceInfo) MarshalBinary() ([]byte, error) {
+	if s.EncryptedPacket {
+		return nil, ErrSpliceInfoEncrypted
+	}
+	cmd := new(bitWriter)
+	switch {
+	case s.CommandType == SpliceInsertCommand && s.SpliceInsert != nil:
+		if err := writeSpliceInsert(cmd, s.SpliceInsert); err != nil {
+			return nil, err
+		}
+	case s.CommandType == TimeSignalCommand && s.TimeSignal != nil:
+		writeSpliceTime(cmd, s.TimeSignal.PTSTime)
+	case s.CommandType != SpliceInsertCommand && s.CommandType != TimeSignalCommand:
+		cmd.bytes(s.RawCommand)
+	default:
+		return nil, fmt.Errorf("no data for splice command %#x", uint8(s.CommandType))
+	}
+
+	descriptors := new(bitWriter)
+	for _, d := range s.Descriptors {
+		body := new(bitWriter)
+		body.write(uint64(d.Identifier), 32)
+		if d.Segmentation != nil {
+			if err := writeSegmentationDescriptor(body, d.Segmentation); err != nil {
+				return nil, err
+			}
+		} else {
+			body.bytes(d.Data)
+		}
+		if len(body.data) > 0xFF {
+			return nil, fmt.Errorf("splice descriptor %#x is too long", d.Tag)
+		}
+		descriptors.write(uint64(d.Tag), 8)
+		descriptors.write(uint64(len(body.data)), 8)
+		descriptors.bytes(body.data)
+	}
+
+	length := 11 + len(cmd.data) + 2 + len(descriptors.data) + 4
+	if len(cmd.data) >= 0xFFF || len(descriptors.data) > 0xFFFF || length > 0xFFF {
+		return nil, errors.New("splice info is too long")
+	}
+	w := new(bitWriter)
+	w.write(spliceInfoTableID, 8)
+	w.write(0, 2) // section_syntax_indicator, private_indicator
+	w.write(uint64(s.SAPType), 2)
+	w.write(uint64(length), 12)
+	w.write(uint64(s.ProtocolVersion), 8)
+	w.flag(false)
+	w.write(uint64(s.EncryptionAlgorithm), 6)
+	w.write(s.PTSAdjustment, 33)
+	w.write(uint64(s.CWIndex), 8)
+	w.write(uint64(s.Tier), 12)
+	w.write(uint64(len(cmd.data)), 12)
+	w.write(uint64(s.CommandType), 8)
+	w.bytes(cmd.data)
+	w.write(uint64(len(descriptors.data)), 16)
+	w.bytes(descriptors.data)
+	w.write(uint64(crc32MPEG(w.data)), 32)
+	return w.data, nil
+}
+
+func readSpliceTime(r *bitReader) *uint64 {
+	if !r.flag() {
+		r.skip(7)
+		return nil
+	}
+	r.skip(6)
+	pts := r.read(33)
+	return &pts
+}
+
+func writeSpliceTime(w *bitWriter, pts *uint64) {
+	if pts == nil {
+		w.write(0x7F, 8)
+		return
+	}
+	w.write(0x7F, 7) // time_specified_flag and reserved bits
+	w.write(*pts, 33)
+}
+
+func readSpliceInsert(r *bitReader) *SpliceInsert {
+	si := new(SpliceInsert)
+	si.EventID = uint32(r.read(32))
+	si.EventCancel = r.flag()
+	r.skip(7)
+	if si.EventCancel {
+		return si
+	}
+	si.OutOfNetwork = r.flag()
+	si.ProgramSplice = r.flag()
+	hasDuration := r.flag()
+	si.SpliceImmediate = r.flag()
+	r.skip(4)
+	if si.ProgramSplice && !si.SpliceImmediate {
+		si.PTSTime = readSpliceTime(r)
+	}
+	if !si.ProgramSplice {
+		for count := r.read(8); count > 0 && r.err == nil; count-- {
+			c := SpliceComponent{Tag: uint8(r.read(8))}
+			if !si.SpliceImmediate {
+				c.PTSTime = readSpliceTime(r)
+			}
+			si.Components = append(si.Components, c)
+		}
+	}
+	if hasDuration {
+		si.BreakDuration = &BreakDuration{AutoReturn: r.flag()}
+		r.skip(6)
+		si.BreakDuration.Duration = r.read(33)
+	}
+	si.UniqueProgramID = uint16(r.read(16))
+	si.AvailNum = uint8(r.read(8))
+	si.AvailsExpected = uint8(r.read(8))
+	return si
+}
+
+func writeSpliceInsert(w *bitWriter, si *SpliceInsert) error {
+	w.write(uint64(si.EventID), 32)
+	w.flag(si.EventCancel)
+	w.write(0x7F, 7)
+	if si.EventCancel {
+		return nil
+	}
+	w.flag(si.OutOfNetwork)
+	w.flag(si.ProgramSplice)
+	w.flag(si.BreakDuration != nil)
+	w.flag(si.SpliceImmediate)
+	w.write(0xF, 4)
+	if si.ProgramSplice && !si.SpliceImmediate {
+		writeSpliceTime(w, si.PTSTime)
+	}
+	if !si.ProgramSplice {
+		if len(si.Components) > 0xFF {
+			return errors.New("too many splice components")
+		}
+		w.write(uint64(len(si.Components)), 8)
+		for _, c := range si.Components {
+			w.write(uint64(c.Tag), 8)
+			if !si.SpliceImmediate {
+				writeSpliceTime(w, c.PTSTime)
+			}
+		}
+	}
+	if si.BreakDuration != nil {
+		w.flag(si.BreakDuration.AutoReturn)
+		w.write(0x3F, 6)
+		w.write(si.BreakDuration.Duration, 33)
+	}
+	w.write(uint64(si.UniqueProgramID), 16)
+	w.write(uint64(si.AvailNum), 8)
+	w.write(uint64(si.AvailsExpected), 8)
+	return nil
+}
+
+func readSegmentationDescriptor(r *bitReader) *SegmentationDescriptor {
+	sd := new(SegmentationDescriptor)
+	sd.EventID = uint32(r.read(32))
+	sd.EventCancel = r.flag()
+	r.skip(7)
+	if sd.EventCancel {
+		return sd
+	}
+	sd.ProgramSegmentation = r.flag()
+	hasDuration := r.flag()
+	sd.DeliveryNotRestricted = r.flag()
+	if sd.DeliveryNotRestricted {
+		r.skip(5)
+	} else {
+		sd.WebDeliveryAllowed = r.flag()
+		sd.NoRegionalBlackout = r.flag()
+		sd.ArchiveAllowed = r.flag()
+		sd.DeviceRestrictions = uint8(r.read(2))
+	}
+	if !sd.ProgramSegmentation {
+		for count := r.read(8); count > 0 && r.err == nil; count-- {
+			c := SegmentationComponent{Tag: uint8(r.read(8))}
+			r.skip(7)
+			c.PTSOffset = r.read(33)
+			sd.Components = append(sd.Components, c)
+		}
+	}
+	if hasDuration {
+		duration := r.read(40)
+		sd.Duration = &duration
+	}
+	sd.UPIDType = uint8(r.read(8))
+	sd.UPID = append([]byte(nil), r.bytes(int(r.read(8)))...)
+	sd.TypeID = uint8(r.read(8))
+	sd.SegmentNum = uint8(r.read(8))
+	sd.SegmentsExpected = uint8(r.read(8))
+	// sub segments are absent in the sections of older encoders
+	if r.err == nil && r.remaining() >= 16 {
+		sd.SubSegments = &SubSegments{Num: uint8(r.read(8)), Expected: uint8(r.read(8))}
+	}
+	return sd
+}
+
+func writeSegmentationDescriptor(w *bitWriter, sd *SegmentationDescriptor) error {
+	w.write(uint64(sd.EventID), 32)
+	w.flag(sd.EventCancel)
+	w.write(0x7F, 7)
+	if sd.EventCancel {
+		return nil
+	}
+	w.flag(sd.ProgramSegmentation)
+	w.flag(sd.Duration != nil)
+	w.flag(sd.DeliveryNotRestricted)
+	if sd.DeliveryNotRestricted {
+		w.write(0x1F, 5)
+	} else {
+		w.flag(sd.WebDeliveryAllowed)
+		w.flag(sd.NoRegionalBlackout)
+		w.flag(sd.ArchiveAllowed)
+		w.write(uint64(sd.DeviceRestrictions), 2)
+	}
+	if !sd.ProgramSegmentation {
+		if len(sd.Components) > 0xFF {
+			return errors.New("too many segmentation components")
+		}
+		w.write(uint64(len(sd.Components)), 8)
+		for _, c := range sd.Components {
+			w.write(uint64(c.Tag), 8)
+			w.write(0x7F, 7)
+			w.write(c.PTSOffset, 33)
+		}
+	}
+	if sd.Duration != nil {
+		w.write(*sd.Duration, 40)
+	}
+	if len(sd.UPID) > 0xFF {
+		return errors.New("segmentation UPID is too long")
+	}
+	w.write(uint64(sd.UPIDType), 8)
+	w.write(uint64(len(sd.UPID)), 8)
+	w.bytes(sd.UPID)
+	w.write(uint64(sd.TypeID), 8)
+	w.write(uint64(sd.SegmentNum), 8)
+	w.write(uint64(sd.SegmentsExpected), 8)
+	if sd.SubSegments != nil {
+		w.write(uint64(sd.SubSegments.Num), 8)
+		w.write(uint64(sd.SubSegments.Expected), 8)
+	}
+	return nil
+}
+
+// bitReader reads big-endian bit fields. The first error stops
+// reading, next reads return zeroes.
+type bitReader struct {
+	data []byte
+	pos  int // in bits
+	err  error
+}
+
+func (r *bitReader) read(n int) uint64 {
+	if r.err != nil {
+		return 0
+	}
+	if r.remaining() < n {
+		r.err = errSpliceInfoShort
+		return 0
+	}
+	var v uint64
+	for i := 0; i < n; i++ {
+		v = v<<1 | uint64(r.data[r.pos/8]>>(7-uint(r.pos%8))&1)
+		r.pos++
+	}
+	return v
+}
+
+func (r *bitReader) flag() bool {
+	return r.read(1) == 1
+}
+
+func (r *bitReader) skip(n int) {
+	r.read(n)
+}
+
+// bytes reads n bytes, the reader must be aligned to a byte.
+func (r *bitReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if r.remaining() < n*8 {
+		r.err = errSpliceInfoShort
+		return nil
+	}
+	b := r.data[r.pos/8 : r.pos/8+n]
+	r.pos += n * 8
+	return b
+}
+
+func (r *bitReader) remaining() int {
+	return len(r.data)*8 - r.pos
+}
+
+// bitWriter writes big-endian bit fields.
+type bitWriter struct {
+	data []byte
+	pos  int // in bits
+}
+
+func (w *bitWriter) write(v uint64, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if w.pos%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		if v>>uint(i)&1 == 1 {
+			w.data[len(w.data)-1] |= 1 << (7 - uint(w.pos%8))
+		}
+		w.pos++
+	}
+}
+
+func (w *bitWriter) flag(b bool) {
+	if b {
+		w.write(1, 1)
+	} else {
+		w.write(0, 1)
+	}
+}
+
+// bytes writes bytes, the writer must be aligned to a byte.
+func (w *bitWriter) bytes(b []byte) {
+	w.data = append(w.data, b...)
+	w.pos += len(b) * 8
+}
+
+var crc32MPEGTable = func() (table [256]uint32) {
+	for i := range table {
+		crc := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04C11DB7
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+// crc32MPEG calculates CRC-32/MPEG-2 used by MPEG-2 sections.
+func crc32MPEG(data []byte) uint32 {
+	crc := uint32(0xFFFFFFFF)
+	for _, b := range data {
+		crc = crc<<8 ^ crc32MPEGTable[byte(crc>>24)^b]
+	}
+	return crc
+}
@@ -0,0 +1,160 @@
+/*
+ SCTE-35 splice info tests.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseSpliceInsert(t *testing.T) {
+	cue := "/DAvAAAAAAAA///wFAVIAACPf+/+c2nALv4AUsz1AAAAAAAKAAhDVUVJAAABNWLbowo="
+	info, err := ParseSpliceInfo(cue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	si := info.SpliceInsert
+	if info.CommandType != SpliceInsertCommand || si == nil {
+		t.Fatalf("Unexpected command: %#x", info.CommandType)
+	}
+	if si.EventID != 0x4800008f || !si.OutOfNetwork || !si.ProgramSplice || si.SpliceImmediate {
+		t.Errorf("Unexpected splice insert: %+v", si)
+	}
+	if pts, ok := info.PTS(); !ok || pts != 0x07369c02e {
+		t.Errorf("Unexpected PTS: %d", pts)
+	}
+	if si.BreakDuration == nil || !si.BreakDuration.AutoReturn || si.BreakDuration.Duration != 5426421 {
+		t.Errorf("Unexpected break duration: %+v", si.BreakDuration)
+	}
+	if len(info.Descriptors) != 1 || info.Descriptors[0].Tag != AvailDescriptorTag ||
+		info.Descriptors[0].Identifier != CUEIIdentifier || hex.EncodeToString(info.Descriptors[0].Data) != "00000135" {
+		t.Errorf("Unexpected descriptors: %+v", info.Descriptors)
+	}
+	if out, err := info.Cue(); err != nil || out != cue {
+		t.Errorf("Unexpected encoded cue: %s (%v)", out, err)
+	}
+}
+
+func TestParseTimeSignalWithSegmentation(t *testing.T) {
+	cue := "/DA0AAAAAAAA///wBQb+cr0AUAAeAhxDVUVJSAAAjn/PAAGlmbAICAAAAAAsoKGKNAIAmsnRfg=="
+	info, err := ParseSpliceInfo(cue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.TimeSignal == nil || info.TimeSignal.PTSTime == nil || *info.TimeSignal.PTSTime != 0x072bd0050 {
+		t.Fatalf("Unexpected time signal: %+v", info.TimeSignal)
+	}
+	if len(info.Descriptors) != 1 || info.Descriptors[0].Segmentation == nil {
+		t.Fatalf("Unexpected descriptors: %+v", info.Descriptors)
+	}
+	sd := info.Descriptors[0].Segmentation
+	if sd.EventID != 0x4800008e || sd.TypeID != SegmentationProviderPlacementOpportunity ||
+		sd.SegmentNum != 2 || sd.SegmentsExpected != 0 || sd.DurationSeconds() != 307 {
+		t.Errorf("Unexpected segmentation descriptor: %+v", sd)
+	}
+	if sd.UPIDType != 0x08 || hex.EncodeToString(sd.UPID) != "000000002ca0a18a" {
+		t.Errorf("Unexpected UPID: %#x %x", sd.UPIDType, sd.UPID)
+	}
+	if out, err := info.Cue(); err != nil || out != cue {
+		t.Errorf("Unexpected encoded cue: %s (%v)", out, err)
+	}
+}
+
+func TestSpliceInfoFromPlaylist(t *testing.T) {
+	f, err := os.Open("sample-playlists/media-playlist-with-oatcls-scte35.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewMediaPlaylist(3, 3)
+	if err != nil {
+		t.Fatalf("Create media playlist failed: %s", err)
+	}
+	if err = p.DecodeFrom(bufio.NewReader(f), true); err != nil {
+		t.Fatal(err)
+	}
+	info, err := p.Segments[0].SCTE.SpliceInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.SpliceInsert == nil || info.SpliceInsert.BreakDuration.Seconds() != 15 {
+		t.Errorf("Unexpected splice info: %+v", info)
+	}
+}
+
+func TestSpliceInfoRoundTrip(t *testing.T) {
+	pts := uint64(1 << 32)
+	duration := uint64(30 * PTSClock)
+	info := &SpliceInfo{
+		SAPType:       3,
+		PTSAdjustment: 1 << 32,
+		Tier:          0xFFF,
+		CommandType:   TimeSignalCommand,
+		TimeSignal:    &TimeSignal{PTSTime: &pts},
+		Descriptors: []SpliceDescriptor{{
+			Tag:        SegmentationDescriptorTag,
+			Identifier: CUEIIdentifier,
+			Segmentation: &SegmentationDescriptor{
+				EventID:               42,
+				DeliveryNotRestricted: true,
+				Components:            []SegmentationComponent{{Tag: 1, PTSOffset: 100}},
+				Duration:              &duration,
+				UPIDType:              0x09,
+				UPID:                  []byte("SIGNAL:abc"),
+				TypeID:                SegmentationDistributorPlacementOpportunity,
+				SegmentNum:            1,
+				SegmentsExpected:      1,
+				SubSegments:           &SubSegments{Num: 1, Expected: 2},
+			},
+		}},
+	}
+	data, err := info.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ParseSpliceInfo("0x" + hex.EncodeToString(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(info, decoded) {
+		t.Errorf("Decoded splice info differs:\n%+v\n%+v", info, decoded)
+	}
+	if adjusted, _ := decoded.PTS(); adjusted != 0 {
+		t.Errorf("PTS adjustment must wrap around 33 bits, got: %d", adjusted)
+	}
+
+	cancel := &SpliceInfo{CommandType: SpliceInsertCommand, SpliceInsert: &SpliceInsert{EventID: 7, EventCancel: true}}
+	cue, err := cancel.Cue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded, err = ParseSpliceInfo(cue); err != nil || !reflect.DeepEqual(cancel, decoded) {
+		t.Errorf("Decoded splice info differs: %+v (%v)", decoded, err)
+	}
+}
+
+func TestParseSpliceInfoErrors(t *testing.T) {
+	data, _ := base64.StdEncoding.DecodeString("/DAlAAAAAAAAAP/wFAUAAAABf+/+ANgNkv4AFJlwAAEBAQAA5xULLA==")
+	data[20] ^= 0xFF
+	if _, err := ParseSpliceInfo(base64.StdEncoding.EncodeToString(data)); err != ErrSpliceInfoCRC {
+		t.Errorf("Expected CRC error, got: %v", err)
+	}
+	for _, cue := range []string{"not base64!", "AAAA", "/DAl"} {
+		if _, err := ParseSpliceInfo(cue); err == nil {
+			t.Errorf("Expected error for %q", cue)
+		}
+	}
+	if _, err := (&SpliceInfo{CommandType: TimeSignalCommand}).MarshalBinary(); err == nil {
+		t.Error("Expected error for time signal without data")
+	}
+}
@@ -0,0 +1,114 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines functions related to ordered access to segments of
+ media playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+// MediaPlaylist.Segments is the ring buffer: segments added to the
+// tail and removed from the head, so the first segment of the
+// playlist is not always at the start of the slice. Functions below
+// walk the buffer in the playlist order.
+
+// ForEach calls fn for each segment of the playlist in order from the
+// oldest one. Nil entries of badly filled playlists are skipped.
+// Iteration stops when fn returns false.
+func (p *MediaPlaylist) ForEach(fn func(seg *MediaSegment) bool) {
+	head := p.head
+	for count := p.count; count > 0; count-- {
+		seg := p.Segments[head]
+		head = (head + 1) % p.capacity
+		if seg == nil { // protection from badly filled chunklists
+			continue
+		}
+		if !fn(seg) {
+			return
+		}
+	}
+}
+
+// OrderedSegments returns a new slice with all segments of the
+// playlist in order from the oldest one and without nil entries.
+func (p *MediaPlaylist) OrderedSegments() []*MediaSegment {
+	segments := make([]*MediaSegment, 0, p.count)
+	p.ForEach(func(seg *MediaSegment) bool {
+		segments = append(segments, seg)
+		return true
+	})
+	return segments
+}
+
+// Window returns segments exactly as they displayed by Encode: first
+// `winsize` segments from the oldest one or all segments for playlists
+// with zero window size (VOD).
+func (p *MediaPlaylist) Window() []*MediaSegment {
+	size := p.count
+	if p.winsize > 0 && p.winsize < size {
+		size = p.winsize
+	}
+	segments := make([]*MediaSegment, 0, size)
+	p.ForEach(func(seg *MediaSegment) bool {
+		segments = append(segments, seg)
+		return p.winsize == 0 || uint(len(segments)) < p.winsize
+	})
+	return segments
+}
+
+// SegmentByIndex returns the segment at position i counting from the
+// oldest segment of the playlist. It returns nil if the index is out
+// of range.
+func (p *MediaPlaylist) SegmentByIndex(i uint) *MediaSegment {
+	if i >= p.count {
+		return nil
+	}
+	return p.Segments[(p.head+i)%p.capacity]
+}
+
+// SegmentBySeqId returns the segment with the media sequence number
+// or nil if the playlist has no such segment.
+func (p *MediaPlaylist) SegmentBySeqId(id uint64) *MediaSegment {
+	// sequence numbers usually go without gaps so try direct access first
+	if first := p.First(); first != nil && id >= first.SeqId && id-first.SeqId < uint64(p.count) {
+		if seg := p.SegmentByIndex(uint(id - first.SeqId)); seg != nil && seg.SeqId == id {
+			return seg
+		}
+	}
+	var found *MediaSegment
+	p.ForEach(func(seg *MediaSegment) bool {
+		if seg.SeqId == id {
+			found = seg
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// First returns the oldest segment of the playlist or nil for empty
+// playlists.
+func (p *MediaPlaylist) First() *MediaSegment {
+	var first *MediaSegment
+	p.ForEach(func(seg *MediaSegment) bool {
+		first = seg
+		return false
+	})
+	return first
+}
+
+// Last returns the most recently added segment of the playlist or nil
+// for empty playlists.
+func (p *MediaPlaylist) Last() *MediaSegment {
+	for i := p.count; i > 0; i-- {
+		if seg := p.SegmentByIndex(i - 1); seg != nil {
+			return seg
+		}
+	}
+	return nil
+}
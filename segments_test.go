@@ -0,0 +1,91 @@
+/*
+ Ordered segments access tests.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestOrderedSegments(t *testing.T) {
+	p, e := NewMediaPlaylist(3, 5)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	if p.First() != nil || p.Last() != nil || len(p.OrderedSegments()) != 0 || len(p.Window()) != 0 {
+		t.Error("Empty playlist must have no segments")
+	}
+	for i := 0; i < 5; i++ {
+		_ = p.Append(fmt.Sprintf("test%d.ts", i), 5.0, "")
+	}
+	// rotate the head of the buffer
+	_ = p.Remove()
+	_ = p.Remove()
+	_ = p.Append("test5.ts", 5.0, "")
+
+	var uris []string
+	for _, seg := range p.OrderedSegments() {
+		uris = append(uris, seg.URI)
+	}
+	if strings.Join(uris, ",") != "test2.ts,test3.ts,test4.ts,test5.ts" {
+		t.Errorf("Unexpected order of segments: %v", uris)
+	}
+	if p.First().URI != "test2.ts" || p.Last().URI != "test5.ts" {
+		t.Errorf("Unexpected first/last segments: %s/%s", p.First().URI, p.Last().URI)
+	}
+	if seg := p.SegmentByIndex(3); seg == nil || seg.URI != "test5.ts" {
+		t.Errorf("Unexpected segment by index: %v", seg)
+	}
+	if seg := p.SegmentByIndex(4); seg != nil {
+		t.Errorf("Expected nil for index out of range, got: %v", seg)
+	}
+	if seg := p.SegmentBySeqId(4); seg == nil || seg.URI != "test4.ts" {
+		t.Errorf("Unexpected segment by SeqId: %v", seg)
+	}
+	if seg := p.SegmentBySeqId(1); seg != nil {
+		t.Errorf("Removed segment must not be found, got: %v", seg)
+	}
+
+	var visited int
+	p.ForEach(func(seg *MediaSegment) bool {
+		visited++
+		return seg.URI != "test3.ts"
+	})
+	if visited != 2 {
+		t.Errorf("ForEach must stop when callback returns false, visited: %d", visited)
+	}
+}
+
+func TestWindowMatchesEncode(t *testing.T) {
+	p, e := NewMediaPlaylist(3, 6)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	for i := 0; i < 6; i++ {
+		_ = p.Append(fmt.Sprintf("test%d.ts", i), 5.0, "")
+	}
+	window := p.Window()
+	if len(window) != 3 {
+		t.Fatalf("Expected window of 3 segments, got: %d", len(window))
+	}
+	out := p.String()
+	for i := 0; i < 6; i++ {
+		uri := fmt.Sprintf("test%d.ts", i)
+		inWindow := i < len(window) && window[i].URI == uri
+		if strings.Contains(out, uri) != inWindow {
+			t.Errorf("Window and encoded playlist disagree for %s", uri)
+		}
+	}
+	_ = p.SetWinSize(0)
+	if len(p.Window()) != 6 {
+		t.Errorf("Window of VOD playlist must include all segments, got: %d", len(p.Window()))
+	}
+}
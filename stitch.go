@@ -0,0 +1,299 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines server-side ad insertion into media playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"errors"
+)
+
+// Stitcher inserts ads into ad breaks of content media playlists.
+// Content segments inside of each break are replaced with segments of
+// the ad playlist for the break, EXT-X-DISCONTINUITY is placed at the
+// boundaries of ads. When the ads are shorter than the break the rest
+// of the break is filled with repeated segments of the filler
+// playlist or, if there is no filler, the content is resumed.
+//
+// Stitcher keeps the state between calls of Stitch so refreshes of a
+// live content playlist get the same ads for the same breaks and
+// monotonic media and discontinuity sequences. Stitcher is not safe
+// for concurrent use.
+type Stitcher struct {
+	ads    func(b *AdBreak) *MediaPlaylist
+	filler *MediaPlaylist
+
+	records map[uint64]*stitchRecord // by sequence numbers of content segments
+	breaks  map[uint64]*stitchSource // ads by keys of breaks
+	fill    *stitchSource            // prepared filler
+	started bool
+	nextSeq uint64        // media sequence of the next stitched segment
+	discSeq uint64        // discontinuities before the next stitched segment
+	lastSrc *stitchSource // source of the last stitched segment, nil for content
+}
+
+// stitchRecord keeps stitched segments produced for a content segment.
+type stitchRecord struct {
+	seqNo      uint64 // media sequence of the first stitched segment
+	discSeq    uint64 // discontinuities before the first stitched segment
+	breakKey   uint64
+	inBreak    bool
+	elapsedEnd float64 // elapsed time of the break at the end of the content segment
+	segments   []*MediaSegment
+}
+
+// stitchSource is an ad or filler playlist prepared for stitching.
+type stitchSource struct {
+	segments []*MediaSegment // with the keys and the map in force for them
+	duration float64
+}
+
+// NewStitcher creates the stitcher. The ads function is called once
+// for each break and returns its ad playlist, nil keeps the content of
+// the break. Filler is optional.
+func NewStitcher(ads func(b *AdBreak) *MediaPlaylist, filler *MediaPlaylist) *Stitcher {
+	return &Stitcher{
+		ads:     ads,
+		filler:  filler,
+		records: make(map[uint64]*stitchRecord),
+		breaks:  make(map[uint64]*stitchSource),
+	}
+}
+
+// Stitch inserts ads into the ad breaks of the content playlist and
+// returns the stitched playlist. Breaks are found with AdBreaks,
+// malformed cue sequences are stitched as far as they could be.
+func Stitch(content *MediaPlaylist, ads []*MediaPlaylist, filler *MediaPlaylist) (*MediaPlaylist, error) {
+	var n int
+	s := NewStitcher(func(*AdBreak) *MediaPlaylist {
+		n++
+		if n > len(ads) {
+			return nil
+		}
+		return ads[n-1]
+	}, filler)
+	return s.Stitch(content)
+}
+
+// Stitch returns the content playlist with inserted ads. Segments of
+// the previous calls which are still in the content playlist are
+// stitched the same way.
+func (s *Stitcher) Stitch(content *MediaPlaylist) (*MediaPlaylist, error) {
+	timeline := content.Timeline()
+	if len(timeline) == 0 {
+		return nil, errors.New("playlist is empty")
+	}
+	if !s.started {
+		s.nextSeq = timeline[0].Segment.SeqId
+		s.discSeq = content.DiscontinuitySeq
+		s.started = true
+	}
+	breaks, _ := content.AdBreaks()
+	breakOf := make(map[*MediaSegment]*AdBreak)
+	for _, b := range breaks {
+		for _, seg := range b.Segments {
+			breakOf[seg] = b
+		}
+	}
+	contentSegments := prepareStitchSource(content).segments
+
+	records := make([]*stitchRecord, len(timeline))
+	var count uint
+	for i, e := range timeline {
+		r, ok := s.records[e.Segment.SeqId]
+		if !ok {
+			r = s.stitch(e.Segment, contentSegments[i], breakOf[e.Segment])
+			s.records[e.Segment.SeqId] = r
+		}
+		records[i] = r
+		count += uint(len(r.segments))
+	}
+	s.cleanup(records)
+
+	out, err := NewMediaPlaylist(0, count+1)
+	if err != nil {
+		return nil, err
+	}
+	content.copyHeaderTo(out)
+	out.MediaType = content.MediaType
+	out.SeqNo = records[0].seqNo
+	out.DiscontinuitySeq = records[0].discSeq
+	c := newCloner()
+	for _, r := range records {
+		for _, seg := range r.segments {
+			if err = out.AppendSegment(c.segment(seg)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if content.Closed {
+		out.Close()
+	}
+	return out, nil
+}
+
+// stitch produces the segments for the content segment which was not
+// stitched yet.
+func (s *Stitcher) stitch(seg, prepared *MediaSegment, b *AdBreak) *stitchRecord {
+	r := &stitchRecord{seqNo: s.nextSeq, discSeq: s.discSeq}
+	var src *stitchSource
+	if b != nil {
+		r.inBreak = true
+		r.breakKey = b.FirstSeqId()
+		if first, ok := s.records[b.FirstSeqId()]; ok && first.inBreak {
+			r.breakKey = first.breakKey
+		}
+		var ok bool
+		if src, ok = s.breaks[r.breakKey]; !ok {
+			if ads := s.ads(b); ads != nil {
+				src = prepareStitchSource(ads)
+			}
+			s.breaks[r.breakKey] = src
+		}
+		from, _ := b.ElapsedAt(seg.SeqId)
+		if prev, ok := s.records[seg.SeqId-1]; ok && prev.inBreak && prev.breakKey == r.breakKey {
+			from = prev.elapsedEnd
+		}
+		r.elapsedEnd = from + seg.Duration
+		if src != nil {
+			for i, item := range s.fillBreak(src, from, r.elapsedEnd) {
+				if i == 0 && seg.SCTE != nil {
+					// cues of the break are kept on the ads
+					scte := *seg.SCTE
+					item.SCTE = &scte
+				}
+				r.segments = append(r.segments, item)
+			}
+			if len(r.segments) > 0 || from < src.duration || s.fill != nil && s.fill.duration > 0 {
+				s.commit(r)
+				return r
+			}
+		}
+	}
+	// the content outside of breaks, in breaks without ads and after
+	// ads without filler
+	if s.lastSrc != nil {
+		prepared.Discontinuity = true
+	}
+	s.lastSrc = nil
+	r.segments = []*MediaSegment{prepared}
+	s.commit(r)
+	return r
+}
+
+// fillBreak returns the ad and filler segments which start inside of
+// the range of the break elapsed time. Segments come with the source
+// playlist marked by the discontinuity flag.
+func (s *Stitcher) fillBreak(src *stitchSource, from, to float64) []*MediaSegment {
+	var (
+		items  []*MediaSegment
+		offset float64
+	)
+	for _, seg := range src.segments {
+		if offset >= from && offset < to {
+			items = append(items, s.source(seg, src, false))
+		}
+		offset += seg.Duration
+	}
+	if s.filler == nil {
+		return items
+	}
+	if s.fill == nil {
+		s.fill = prepareStitchSource(s.filler)
+	}
+	if s.fill.duration <= 0 {
+		return items
+	}
+	for cycle := 0; offset < to; cycle++ {
+		for i, seg := range s.fill.segments {
+			if offset >= to {
+				break
+			}
+			if offset >= from {
+				// timestamps restart with each repetition of the filler
+				items = append(items, s.source(seg, s.fill, i == 0 && cycle > 0))
+			}
+			offset += seg.Duration
+		}
+	}
+	return items
+}
+
+// source returns the copy of the segment marked as discontinuity when
+// it comes from another source than the previous one.
+func (s *Stitcher) source(seg *MediaSegment, src *stitchSource, restart bool) *MediaSegment {
+	cp := newCloner().segment(seg)
+	if src != s.lastSrc || restart {
+		cp.Discontinuity = true
+	}
+	s.lastSrc = src
+	return cp
+}
+
+// commit advances sequences by the stitched segments of the record.
+func (s *Stitcher) commit(r *stitchRecord) {
+	for _, seg := range r.segments {
+		s.nextSeq++
+		if seg.Discontinuity {
+			s.discSeq++
+		}
+	}
+}
+
+// cleanup forgets the segments which left the content playlist.
+func (s *Stitcher) cleanup(records []*stitchRecord) {
+	keep := make(map[*stitchRecord]bool, len(records))
+	keys := make(map[uint64]bool)
+	for _, r := range records {
+		keep[r] = true
+		if r.inBreak {
+			keys[r.breakKey] = true
+		}
+	}
+	for seqId, r := range s.records {
+		if !keep[r] {
+			delete(s.records, seqId)
+		}
+	}
+	for key := range s.breaks {
+		if !keys[key] {
+			delete(s.breaks, key)
+		}
+	}
+}
+
+// prepareStitchSource copies segments of the playlist with the key and
+// the map in force for each of them. URIs are resolved against the base
+// URL of the playlist if it is set.
+func prepareStitchSource(p *MediaPlaylist) *stitchSource {
+	if p.BaseURL != nil {
+		p = p.Clone()
+		_ = p.ResolveURIs()
+	}
+	src := new(stitchSource)
+	c := newCloner()
+	key, keys, xmap := p.Key, p.Keys, p.Map
+	for _, seg := range p.OrderedSegments() {
+		if seg.Key != nil {
+			key, keys = seg.Key, seg.Keys
+		}
+		if seg.Map != nil {
+			xmap = seg.Map
+		}
+		cp := c.segment(seg)
+		cp.Key, cp.Keys, cp.Map = c.key(key), c.keyList(keys), c.xmap(xmap)
+		if cp.Key == nil {
+			cp.Key = &Key{Method: "NONE"}
+		}
+		src.segments = append(src.segments, cp)
+		src.duration += seg.Duration
+	}
+	return src
+}
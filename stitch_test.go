@@ -0,0 +1,154 @@
+/*
+ Ad stitching tests.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// stitchContent appends content segments of 4 seconds, segments 2 and
+// 3 make the ad break of 8 seconds.
+func stitchContent(t *testing.T, p *MediaPlaylist, from, to int) {
+	for i := from; i < to; i++ {
+		if e := p.Append(fmt.Sprintf("c%d.ts", i), 4, ""); e != nil {
+			t.Fatalf("Add segment to a media playlist failed: %s", e)
+		}
+		switch i {
+		case 2:
+			_ = p.SetSCTE35(&SCTE{Syntax: SCTE35_OATCLS, CueType: SCTE35Cue_Start, Time: 8})
+		case 4:
+			_ = p.SetSCTE35(&SCTE{Syntax: SCTE35_OATCLS, CueType: SCTE35Cue_End})
+		}
+	}
+}
+
+func stitchAds(t *testing.T, uris ...string) *MediaPlaylist {
+	p, e := NewMediaPlaylist(0, uint(len(uris)))
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	for _, uri := range uris {
+		_ = p.Append(uri, 3, "")
+	}
+	p.Close()
+	return p
+}
+
+func stitchURIs(p *MediaPlaylist) string {
+	var uris []string
+	for _, seg := range p.OrderedSegments() {
+		uri := seg.URI
+		if seg.Discontinuity {
+			uri = "|" + uri
+		}
+		uris = append(uris, uri)
+	}
+	return strings.Join(uris, " ")
+}
+
+func TestStitchWithFiller(t *testing.T) {
+	content, _ := NewMediaPlaylist(0, 6)
+	stitchContent(t, content, 0, 6)
+	content.Close()
+	ads := stitchAds(t, "ad0.ts")
+	_ = ads.SetKey("AES-128", "https://example.com/ad.key", "", "", "")
+	filler, _ := NewMediaPlaylist(0, 1)
+	_ = filler.Append("filler.ts", 2, "")
+
+	p, err := Stitch(content, []*MediaPlaylist{ads}, filler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "c0.ts c1.ts |ad0.ts |filler.ts |filler.ts |filler.ts |c4.ts c5.ts"
+	if got := stitchURIs(p); got != expected {
+		t.Errorf("Expected segments %q, got %q", expected, got)
+	}
+	if !p.Closed || p.SeqNo != 0 || p.DiscontinuitySeq != 0 {
+		t.Errorf("Unexpected header: closed %v, seq %d, discontinuity seq %d", p.Closed, p.SeqNo, p.DiscontinuitySeq)
+	}
+	out := p.Encode().String()
+	for _, tag := range []string{
+		"#EXT-X-CUE-OUT:8\n#EXT-X-KEY:METHOD=AES-128,URI=\"https://example.com/ad.key\"\n#EXT-X-DISCONTINUITY\n#EXTINF:3.000,\nad0.ts",
+		"#EXT-X-KEY:METHOD=NONE\n#EXT-X-DISCONTINUITY\n#EXTINF:2.000,\nfiller.ts",
+		"#EXT-X-CUE-IN\n#EXT-X-DISCONTINUITY\n#EXTINF:4.000,\nc4.ts",
+	} {
+		if !strings.Contains(out, tag) {
+			t.Errorf("Expected %q in the stitched playlist:\n%s", tag, out)
+		}
+	}
+}
+
+func TestStitchResumesContent(t *testing.T) {
+	content, _ := NewMediaPlaylist(0, 6)
+	stitchContent(t, content, 0, 6)
+	content.Close()
+
+	p, err := Stitch(content, []*MediaPlaylist{stitchAds(t, "ad0.ts")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "c0.ts c1.ts |ad0.ts |c3.ts c4.ts c5.ts"
+	if got := stitchURIs(p); got != expected {
+		t.Errorf("Expected segments %q, got %q", expected, got)
+	}
+
+	// breaks without ads keep the content
+	p, err = Stitch(content, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = "c0.ts c1.ts c2.ts c3.ts c4.ts c5.ts"
+	if got := stitchURIs(p); got != expected {
+		t.Errorf("Expected segments %q, got %q", expected, got)
+	}
+}
+
+func TestStitcherLiveRefresh(t *testing.T) {
+	content, _ := NewMediaPlaylist(0, 10)
+	stitchContent(t, content, 0, 4)
+	var calls int
+	s := NewStitcher(func(b *AdBreak) *MediaPlaylist {
+		calls++
+		return stitchAds(t, "ad0.ts", "ad1.ts", "ad2.ts")
+	}, nil)
+
+	expected := []struct {
+		uris    string
+		seqNo   uint64
+		discSeq uint64
+	}{
+		{"c0.ts c1.ts |ad0.ts ad1.ts ad2.ts", 0, 0},
+		{"c1.ts |ad0.ts ad1.ts ad2.ts |c4.ts", 1, 0},
+		{"|ad0.ts ad1.ts ad2.ts |c4.ts c5.ts", 2, 0},
+		{"ad2.ts |c4.ts c5.ts c6.ts", 4, 1},
+		{"|c4.ts c5.ts c6.ts c7.ts", 5, 1},
+	}
+	for i, exp := range expected {
+		if i > 0 {
+			_ = content.Remove()
+			stitchContent(t, content, i+3, i+4)
+		}
+		p, err := s.Stitch(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := stitchURIs(p); got != exp.uris {
+			t.Errorf("Refresh %d: expected segments %q, got %q", i, exp.uris, got)
+		}
+		if p.SeqNo != exp.seqNo || p.DiscontinuitySeq != exp.discSeq {
+			t.Errorf("Refresh %d: expected sequences %d/%d, got %d/%d", i, exp.seqNo, exp.discSeq, p.SeqNo, p.DiscontinuitySeq)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected ads requested once, got %d", calls)
+	}
+}
@@ -14,6 +14,7 @@ package m3u8
 import (
 	"bytes"
 	"io"
+	"net/url"
 	"time"
 )
 
@@ -57,15 +58,20 @@ const (
 	VOD
 )
 
-// SCTE35Syntax defines the format of the SCTE-35 cue points which do not use
-// the draft-pantos-http-live-streaming-19 EXT-X-DATERANGE tag and instead
-// have their own custom tags
+// SCTE35Syntax defines the format of the SCTE-35 cue points. Most of
+// them do not use the draft-pantos-http-live-streaming-19
+// EXT-X-DATERANGE tag and instead have their own custom tags
 type SCTE35Syntax uint
 
 const (
 	// SCTE35_67_2014 will be the default due to backwards compatibility reasons.
-	SCTE35_67_2014 SCTE35Syntax = iota // SCTE35_67_2014 defined in http://www.scte.org/documents/pdf/standards/SCTE%2067%202014.pdf
-	SCTE35_OATCLS                      // SCTE35_OATCLS is a non-standard but common format
+	SCTE35_67_2014     SCTE35Syntax = iota // SCTE35_67_2014 defined in http://www.scte.org/documents/pdf/standards/SCTE%2067%202014.pdf
+	SCTE35_OATCLS                          // SCTE35_OATCLS is a non-standard but common format
+	SCTE35_ELEMENTAL                       // SCTE35_ELEMENTAL uses EXT-X-CUE-OUT:DURATION=, EXT-X-CUE-OUT-CONT:elapsed/duration and EXT-X-CUE-IN
+	SCTE35_ANVATO                          // SCTE35_ANVATO uses EXT-X-CUE-OUT:duration and EXT-X-CUE-OUT-CONT with upper-case attributes (Anvato, AWS)
+	SCTE35_ADOBE                           // SCTE35_ADOBE uses EXT-X-CUE with TYPE="SpliceOut" and TYPE="SpliceIn"
+	SCTE35_SPLICEPOINT                     // SCTE35_SPLICEPOINT uses EXT-X-SPLICEPOINT-SCTE35 with the cue only
+	SCTE35_DATERANGE                       // SCTE35_DATERANGE uses EXT-X-DATERANGE with SCTE35-OUT and SCTE35-IN attributes
 )
 
 // SCTE35CueType defines the type of cue point, used by readers and writers to
@@ -78,6 +84,15 @@ const (
 	SCTE35Cue_End                        // SCTE35Cue_End indicates an in cue point
 )
 
+// TargetDurationRounding defines how segment durations are rounded to
+// the integer EXT-X-TARGETDURATION.
+type TargetDurationRounding uint
+
+const (
+	TargetDurationCeil  TargetDurationRounding = iota // TargetDurationCeil rounds durations up, the default
+	TargetDurationRound                               // TargetDurationRound rounds durations to the nearest integer as section 4.3.3.1 allows
+)
+
 // MediaPlaylist structure represents a single bitrate playlist aka
 // media playlist. It related to both a simple media playlists and a
 // sliding window media playlists. URI lines in the Playlist point to
@@ -117,6 +132,7 @@ type MediaPlaylist struct {
 	StartTime        float64
 	StartTimePrecise bool
 	durationAsInt    bool // output durations as integers of floats?
+	targetRounding   TargetDurationRounding
 	keyformat        int
 	winsize          uint // max number of segments displayed in an encoded playlist; need set to zero for VOD playlists
 	capacity         uint // total capacity of slice used for the playlist
@@ -125,11 +141,43 @@ type MediaPlaylist struct {
 	count            uint // number of segments added to the playlist
 	buf              bytes.Buffer
 	ver              uint8
-	Key              *Key // EXT-X-KEY is optional encryption key displayed before any segments (default key for the playlist)
-	Map              *Map // EXT-X-MAP is optional tag specifies how to obtain the Media Initialization Section (default map for the playlist)
-	WV               *WV  // Widevine related tags outside of M3U8 specs
+	Key              *Key   // EXT-X-KEY is optional encryption key displayed before any segments (default key for the playlist)
+	Keys             []*Key // other keys of the default key set for different KEYFORMATs (multi-DRM), displayed after Key
+	Map              *Map   // EXT-X-MAP is optional tag specifies how to obtain the Media Initialization Section (default map for the playlist)
+	WV               *WV    // Widevine related tags outside of M3U8 specs
 	Custom           map[string]CustomTag
+	BaseURL          *url.URL // optional URL of the playlist itself used for resolving of relative URIs
 	customDecoders   []CustomDecoder
+	autoPDT          *autoProgramDateTime // automatic EXT-X-PROGRAM-DATE-TIME for appended segments
+	rotation         *keyRotation         // automatic keys for appended segments
+}
+
+// AutoProgramDateTime defines how EXT-X-PROGRAM-DATE-TIME is set for
+// the segments appended to a media playlist. Wall-clock time of a
+// segment is derived from Start plus durations of all segments
+// appended before it.
+type AutoProgramDateTime struct {
+	Start time.Time // wall-clock time of the next appended segment
+	// Every sets the tag on each Nth segment, zero or one means
+	// each segment.
+	Every uint
+	// ResetOnDiscontinuity sets the tag on each segment marked with
+	// EXT-X-DISCONTINUITY and counts next Nth segments from it.
+	ResetOnDiscontinuity bool
+}
+
+// KeyRotation defines how new encryption keys are assigned to the
+// segments appended to a media playlist. The first appended segment
+// gets the new key set, next sets are assigned after Every segments or
+// after Interval seconds of media since the last key, whatever comes
+// first.
+type KeyRotation struct {
+	Every    uint    // number of segments encrypted with the same key, zero means no limit
+	Interval float64 // media time in seconds encrypted with the same key, zero means no limit
+	// Keys returns the key set for the segment with the sequence
+	// number. Keys of the set are for different KEYFORMATs, the set of
+	// one key is usual.
+	Keys func(seqId uint64) ([]*Key, error)
 }
 
 // MasterPlaylist structure represents a master playlist which
@@ -153,6 +201,7 @@ type MasterPlaylist struct {
 	ver                 uint8
 	independentSegments bool
 	Custom              map[string]CustomTag
+	BaseURL             *url.URL // optional URL of the playlist itself used for resolving of relative URIs
 	customDecoders      []CustomDecoder
 }
 
@@ -172,19 +221,45 @@ type VariantParams struct {
 	Bandwidth        uint32
 	AverageBandwidth uint32 // EXT-X-STREAM-INF only
 	Codecs           string
-	Resolution       string
-	Audio            string // EXT-X-STREAM-INF only
+	CodecList        []Codec // typed CODECS, MasterPlaylist.Append builds Codecs from it when Codecs is empty
+	Resolution       string  // "WxH", see ResolutionValue and SetResolution
+	Audio            string  // EXT-X-STREAM-INF only
 	Video            string
-	Subtitles        string // EXT-X-STREAM-INF only
-	Captions         string // EXT-X-STREAM-INF only
-	Name             string // EXT-X-STREAM-INF only (non standard Wowza/JWPlayer extension to name the variant/quality in UA)
-	Iframe           bool   // EXT-X-I-FRAME-STREAM-INF
-	VideoRange       string
-	HDCPLevel        string
-	FrameRate        float64        // EXT-X-STREAM-INF
+	Subtitles        string         // EXT-X-STREAM-INF only
+	Captions         string         // EXT-X-STREAM-INF only
+	Name             string         // EXT-X-STREAM-INF only (non standard Wowza/JWPlayer extension to name the variant/quality in UA)
+	Iframe           bool           // EXT-X-I-FRAME-STREAM-INF
+	VideoRange       string         // SDR, HLG or PQ, see VideoRangeValue and SetVideoRange
+	HDCPLevel        string         // TYPE-0, TYPE-1 or NONE, see HDCPLevelValue and SetHDCPLevel
+	FrameRate        float64        // EXT-X-STREAM-INF, written with three decimal places
 	Alternatives     []*Alternative // EXT-X-MEDIA
 }
 
+// Resolution is the value of RESOLUTION attribute: the approximate
+// encoded horizontal and vertical resolution of the video.
+type Resolution struct {
+	Width  int
+	Height int
+}
+
+// VideoRange is the value of VIDEO-RANGE attribute.
+type VideoRange string
+
+const (
+	VideoRangeSDR VideoRange = "SDR"
+	VideoRangeHLG VideoRange = "HLG"
+	VideoRangePQ  VideoRange = "PQ"
+)
+
+// HDCPLevel is the value of HDCP-LEVEL attribute.
+type HDCPLevel string
+
+const (
+	HDCPType0 HDCPLevel = "TYPE-0"
+	HDCPType1 HDCPLevel = "TYPE-1"
+	HDCPNone  HDCPLevel = "NONE"
+)
+
 // Alternative structure represents EXT-X-MEDIA tag in variants.
 type Alternative struct {
 	Type     string
@@ -212,6 +287,7 @@ type MediaSegment struct {
 	Limit           int64     // EXT-X-BYTERANGE <n> is length in bytes for the file under URI
 	Offset          int64     // EXT-X-BYTERANGE [@o] is offset from the start of the file under URI
 	Key             *Key      // EXT-X-KEY displayed before the segment and means changing of encryption key (in theory each segment may have own key)
+	Keys            []*Key    // other keys of the key set for different KEYFORMATs (multi-DRM), displayed after Key
 	Map             *Map      // EXT-X-MAP displayed before the segment
 	Discontinuity   bool      // EXT-X-DISCONTINUITY indicates an encoding discontinuity between the media segment that follows it and the one that preceded it (i.e. file format, number and type of tracks, encoding parameters, encoding sequence, timestamp sequence)
 	SCTE            *SCTE     // SCTE-35 used for Ad signaling in HLS
@@ -331,8 +407,10 @@ type decodingState struct {
 	variant            *Variant
 	groups             map[string][]*Alternative
 	xkey               *Key
+	xkeys              []*Key
 	xmap               *Map
 	scte               *SCTE
+	cueOutSyntax       SCTE35Syntax // syntax of the last cue out, EXT-X-CUE-IN is shared by several of them
 	custom             map[string]CustomTag
 }
 
@@ -0,0 +1,216 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines functions related to time-based access to segments
+ of media playlists.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrOutOfRange declares the error returned when the time or offset
+// is outside of the playlist.
+var ErrOutOfRange = errors.New("out of the playlist range")
+
+// ErrNoProgramDateTime declares the error returned when the wall-clock
+// time of the segment can't be derived from EXT-X-PROGRAM-DATE-TIME tags.
+var ErrNoProgramDateTime = errors.New("program date and time is unknown")
+
+// TimelineEntry describes position of a media segment on the playlist
+// timeline.
+type TimelineEntry struct {
+	Segment *MediaSegment
+	// Start is the offset of the segment from the start of the first
+	// segment in the playlist in seconds.
+	Start float64
+	// ProgramDateTime is the wall-clock time of the segment start.
+	// It taken from the EXT-X-PROGRAM-DATE-TIME of the segment or
+	// interpolated from the nearest tagged segments. It is zero if
+	// there are no tagged segments between discontinuities around the
+	// segment.
+	ProgramDateTime time.Time
+}
+
+// End returns the offset of the segment end in seconds.
+func (e TimelineEntry) End() float64 {
+	return e.Start + e.Segment.Duration
+}
+
+// Timeline returns segments of the playlist with their start offsets
+// and wall-clock times. Offsets counted from the oldest segment in
+// the playlist. Wall-clock times are never derived across
+// EXT-X-DISCONTINUITY because the timestamp sequence may restart
+// there. Between two tagged segments time is interpolated linearly,
+// before the first and after the last tagged segments it is
+// extrapolated with EXTINF durations.
+func (p *MediaPlaylist) Timeline() []TimelineEntry {
+	segments := p.OrderedSegments()
+	timeline := make([]TimelineEntry, len(segments))
+	var start float64
+	for i, seg := range segments {
+		timeline[i] = TimelineEntry{Segment: seg, Start: start, ProgramDateTime: seg.ProgramDateTime}
+		start += seg.Duration
+	}
+	for from := 0; from < len(timeline); {
+		to := from + 1
+		for to < len(timeline) && !timeline[to].Segment.Discontinuity {
+			to++
+		}
+		interpolateProgramDateTime(timeline[from:to])
+		from = to
+	}
+	return timeline
+}
+
+// Duration returns the total duration of all segments in the
+// playlist in seconds.
+func (p *MediaPlaylist) Duration() float64 {
+	var duration float64
+	p.ForEach(func(seg *MediaSegment) bool {
+		duration += seg.Duration
+		return true
+	})
+	return duration
+}
+
+// SegmentAt returns the segment which contains the media time offset
+// (in seconds from the start of the oldest segment in the playlist)
+// and the offset inside of the segment.
+func (p *MediaPlaylist) SegmentAt(offset float64) (*MediaSegment, float64, error) {
+	timeline := p.Timeline()
+	i, ok := timelineIndex(timeline, offset)
+	if !ok {
+		return nil, 0, ErrOutOfRange
+	}
+	return timeline[i].Segment, offset - timeline[i].Start, nil
+}
+
+// SegmentAtTime returns the segment which contains the wall-clock time
+// and the offset inside of the segment in seconds. Wall-clock times of
+// the segments are taken from the playlist Timeline.
+func (p *MediaPlaylist) SegmentAtTime(t time.Time) (*MediaSegment, float64, error) {
+	timeline := p.Timeline()
+	for i, e := range timeline {
+		if e.ProgramDateTime.IsZero() || t.Before(e.ProgramDateTime) {
+			continue
+		}
+		end := e.ProgramDateTime.Add(secondsToDuration(e.Segment.Duration))
+		if i+1 < len(timeline) && !timeline[i+1].Segment.Discontinuity && !timeline[i+1].ProgramDateTime.IsZero() {
+			end = timeline[i+1].ProgramDateTime
+		}
+		if t.Before(end) {
+			return e.Segment, t.Sub(e.ProgramDateTime).Seconds(), nil
+		}
+	}
+	return nil, 0, ErrOutOfRange
+}
+
+// OffsetOf returns the media time offset of the segment start with the
+// sequence number in seconds from the start of the oldest segment.
+func (p *MediaPlaylist) OffsetOf(seqId uint64) (float64, error) {
+	for _, e := range p.Timeline() {
+		if e.Segment.SeqId == seqId {
+			return e.Start, nil
+		}
+	}
+	return 0, ErrOutOfRange
+}
+
+// TimeAt returns the wall-clock time for the media time offset (in
+// seconds from the start of the oldest segment in the playlist).
+func (p *MediaPlaylist) TimeAt(offset float64) (time.Time, error) {
+	timeline := p.Timeline()
+	i, ok := timelineIndex(timeline, offset)
+	if !ok {
+		return time.Time{}, ErrOutOfRange
+	}
+	if timeline[i].ProgramDateTime.IsZero() {
+		return time.Time{}, ErrNoProgramDateTime
+	}
+	return timeline[i].ProgramDateTime.Add(secondsToDuration(offset - timeline[i].Start)), nil
+}
+
+// FillProgramDateTime sets ProgramDateTime of the segments without
+// EXT-X-PROGRAM-DATE-TIME to the wall-clock times interpolated from the
+// neighboring tagged segments (see Timeline). Segments between
+// discontinuities without any tagged segment are left as is. It
+// returns the number of filled segments.
+func (p *MediaPlaylist) FillProgramDateTime() int {
+	var filled int
+	for _, e := range p.Timeline() {
+		if e.Segment.ProgramDateTime.IsZero() && !e.ProgramDateTime.IsZero() {
+			e.Segment.ProgramDateTime = e.ProgramDateTime
+			filled++
+		}
+	}
+	if filled > 0 {
+		p.buf.Reset()
+	}
+	return filled
+}
+
+// timelineIndex finds the entry which contains the offset.
+func timelineIndex(timeline []TimelineEntry, offset float64) (int, bool) {
+	if offset < 0 || len(timeline) == 0 {
+		return 0, false
+	}
+	i := sort.Search(len(timeline), func(i int) bool {
+		return timeline[i].Start > offset
+	}) - 1
+	if i < 0 || offset >= timeline[i].End() {
+		return 0, false
+	}
+	return i, true
+}
+
+// interpolateProgramDateTime fills wall-clock times of the segments
+// without EXT-X-PROGRAM-DATE-TIME. Entries must belong to the same
+// discontinuity sequence.
+func interpolateProgramDateTime(timeline []TimelineEntry) {
+	prev := -1
+	for i := range timeline {
+		if timeline[i].Segment.ProgramDateTime.IsZero() {
+			continue
+		}
+		tagged := timeline[i]
+		if prev < 0 {
+			for j := 0; j < i; j++ {
+				timeline[j].ProgramDateTime = tagged.ProgramDateTime.Add(-secondsToDuration(tagged.Start - timeline[j].Start))
+			}
+		} else {
+			from := timeline[prev]
+			span := tagged.Start - from.Start
+			wall := tagged.ProgramDateTime.Sub(from.ProgramDateTime)
+			for j := prev + 1; j < i; j++ {
+				if span > 0 {
+					ratio := (timeline[j].Start - from.Start) / span
+					timeline[j].ProgramDateTime = from.ProgramDateTime.Add(time.Duration(float64(wall) * ratio))
+				} else {
+					timeline[j].ProgramDateTime = from.ProgramDateTime
+				}
+			}
+		}
+		prev = i
+	}
+	if prev < 0 {
+		return
+	}
+	from := timeline[prev]
+	for j := prev + 1; j < len(timeline); j++ {
+		timeline[j].ProgramDateTime = from.ProgramDateTime.Add(secondsToDuration(timeline[j].Start - from.Start))
+	}
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
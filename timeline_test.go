@@ -0,0 +1,147 @@
+/*
+ Playlist timeline tests.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Segments: 0..3 with 4s each, PDT tagged at 0 and 2 (with 1s drift),
+// discontinuity at 4 without PDT, segment 5 tagged.
+func timelinePlaylist(t *testing.T) (*MediaPlaylist, time.Time) {
+	p, e := NewMediaPlaylist(0, 10)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 7; i++ {
+		_ = p.Append(fmt.Sprintf("test%d.ts", i), 4.0, "")
+		switch i {
+		case 0:
+			_ = p.SetProgramDateTime(start)
+		case 2:
+			_ = p.SetProgramDateTime(start.Add(9 * time.Second))
+		case 4:
+			_ = p.SetDiscontinuity()
+		case 5:
+			_ = p.SetProgramDateTime(start.Add(time.Hour))
+		}
+	}
+	return p, start
+}
+
+func TestTimeline(t *testing.T) {
+	p, start := timelinePlaylist(t)
+	if p.Duration() != 28 {
+		t.Errorf("Expected duration 28, got: %v", p.Duration())
+	}
+	tl := p.Timeline()
+	expected := []time.Time{
+		start,
+		start.Add(4500 * time.Millisecond), // interpolated between 0 and 9 s
+		start.Add(9 * time.Second),
+		start.Add(13 * time.Second), // extrapolated with EXTINF
+		start.Add(time.Hour - 4*time.Second),
+		start.Add(time.Hour),
+		start.Add(time.Hour + 4*time.Second),
+	}
+	for i, e := range tl {
+		if e.Start != float64(i*4) {
+			t.Errorf("Segment %d: expected start %d, got: %v", i, i*4, e.Start)
+		}
+		if !e.ProgramDateTime.Equal(expected[i]) {
+			t.Errorf("Segment %d: expected PDT %v, got: %v", i, expected[i], e.ProgramDateTime)
+		}
+	}
+}
+
+func TestTimelineWithoutProgramDateTimeAfterDiscontinuity(t *testing.T) {
+	p, _ := timelinePlaylist(t)
+	p.Segments[5].ProgramDateTime = time.Time{}
+	tl := p.Timeline()
+	if !tl[4].ProgramDateTime.IsZero() || !tl[6].ProgramDateTime.IsZero() {
+		t.Error("PDT must not be derived across discontinuity")
+	}
+	if _, err := p.TimeAt(18); err != ErrNoProgramDateTime {
+		t.Errorf("Expected ErrNoProgramDateTime, got: %v", err)
+	}
+}
+
+func TestFillProgramDateTime(t *testing.T) {
+	p, start := timelinePlaylist(t)
+	p.Segments[5].ProgramDateTime = time.Time{}
+	if filled := p.FillProgramDateTime(); filled != 2 {
+		t.Errorf("Expected 2 filled segments, got: %d", filled)
+	}
+	if !p.Segments[1].ProgramDateTime.Equal(start.Add(4500*time.Millisecond)) ||
+		!p.Segments[3].ProgramDateTime.Equal(start.Add(13*time.Second)) {
+		t.Errorf("Unexpected filled PDT: %v, %v", p.Segments[1].ProgramDateTime, p.Segments[3].ProgramDateTime)
+	}
+	for i := 4; i < 7; i++ {
+		if !p.Segments[i].ProgramDateTime.IsZero() {
+			t.Errorf("Segment %d: PDT must not be derived across discontinuity", i)
+		}
+	}
+	if filled := p.FillProgramDateTime(); filled != 0 {
+		t.Errorf("Expected nothing to fill, got: %d", filled)
+	}
+}
+
+func TestSegmentAt(t *testing.T) {
+	p, _ := timelinePlaylist(t)
+	seg, inner, err := p.SegmentAt(9.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seg.URI != "test2.ts" || inner != 1.5 {
+		t.Errorf("Unexpected segment %s with offset %v", seg.URI, inner)
+	}
+	if seg, _, _ = p.SegmentAt(0); seg.URI != "test0.ts" {
+		t.Errorf("Unexpected segment at zero offset: %s", seg.URI)
+	}
+	for _, offset := range []float64{-1, 28, 100} {
+		if _, _, err = p.SegmentAt(offset); err != ErrOutOfRange {
+			t.Errorf("Expected ErrOutOfRange for %v, got: %v", offset, err)
+		}
+	}
+	if offset, err := p.OffsetOf(5); err != nil || offset != 20 {
+		t.Errorf("Unexpected offset of segment: %v, %v", offset, err)
+	}
+	if _, err := p.OffsetOf(100); err != ErrOutOfRange {
+		t.Errorf("Expected ErrOutOfRange, got: %v", err)
+	}
+}
+
+func TestSegmentAtTime(t *testing.T) {
+	p, start := timelinePlaylist(t)
+	seg, inner, err := p.SegmentAtTime(start.Add(10 * time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seg.URI != "test2.ts" || inner != 1 {
+		t.Errorf("Unexpected segment %s with offset %v", seg.URI, inner)
+	}
+	// end of the interpolated segment is the start of the next one
+	if seg, _, _ = p.SegmentAtTime(start.Add(8 * time.Second)); seg.URI != "test1.ts" {
+		t.Errorf("Unexpected segment for interpolated time: %s", seg.URI)
+	}
+	if seg, inner, _ = p.SegmentAtTime(start.Add(time.Hour + 5*time.Second)); seg.URI != "test6.ts" || inner != 1 {
+		t.Errorf("Unexpected segment after discontinuity: %s with offset %v", seg.URI, inner)
+	}
+	if _, _, err = p.SegmentAtTime(start.Add(time.Minute)); err != ErrOutOfRange {
+		t.Errorf("Expected ErrOutOfRange for the gap between discontinuities, got: %v", err)
+	}
+	tm, err := p.TimeAt(22)
+	if err != nil || !tm.Equal(start.Add(time.Hour+2*time.Second)) {
+		t.Errorf("Unexpected time for offset: %v, %v", tm, err)
+	}
+}
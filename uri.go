@@ -0,0 +1,303 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines functions related to resolution of playlist URIs.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// URIKind tells which element of the playlist the URI belongs to.
+type URIKind uint
+
+const (
+	URIVariant         URIKind = iota // URIVariant is the URI line after EXT-X-STREAM-INF
+	URIIframe                         // URIIframe is the URI attribute of EXT-X-I-FRAME-STREAM-INF
+	URIAlternative                    // URIAlternative is the URI attribute of EXT-X-MEDIA
+	URISegment                        // URISegment is the URI line of a media segment
+	URIKey                            // URIKey is the URI attribute of EXT-X-KEY
+	URIMap                            // URIMap is the URI attribute of EXT-X-MAP
+	URIPart                           // URIPart is the URI attribute of EXT-X-PART
+	URIPreloadHint                    // URIPreloadHint is the URI attribute of EXT-X-PRELOAD-HINT
+	URIRenditionReport                // URIRenditionReport is the URI attribute of EXT-X-RENDITION-REPORT
+)
+
+// The library doesn't decode low-latency tags yet so URIPart,
+// URIPreloadHint and URIRenditionReport are not passed to rewriters.
+// They are reserved to keep the values of kinds stable.
+
+// URIRewriter is called for every URI of the playlist. It returns
+// the new value of the URI. Empty URIs (for example of the key with
+// METHOD=NONE) are not passed to the rewriter.
+type URIRewriter func(kind URIKind, uri string) (string, error)
+
+// ErrNoBaseURL declares the error returned when URIs resolved without
+// the base URL of the playlist.
+var ErrNoBaseURL = errors.New("base URL of the playlist is not set")
+
+// SetBaseURL sets the URL of the master playlist itself. Relative
+// URIs of the playlist are resolved against it. The base URL is kept
+// by Decode so it may be set before or after decoding.
+func (p *MasterPlaylist) SetBaseURL(rawurl string) error {
+	base, err := url.Parse(rawurl)
+	if err != nil {
+		return err
+	}
+	p.BaseURL = base
+	return nil
+}
+
+// ResolveURIs rewrites URIs of variants and alternative renditions to
+// the absolute form using the base URL of the playlist. This
+// operation does reset playlist cache.
+func (p *MasterPlaylist) ResolveURIs() error {
+	if p.BaseURL == nil {
+		return ErrNoBaseURL
+	}
+	return p.RewriteURIs(func(_ URIKind, uri string) (string, error) {
+		return resolveURI(p.BaseURL, uri)
+	})
+}
+
+// RelativizeURIs rewrites absolute URIs of variants and alternative
+// renditions to paths relative to the new base URL and then sets it
+// as the base URL of the playlist. URIs pointed to other hosts are
+// left as is. This operation does reset playlist cache.
+func (p *MasterPlaylist) RelativizeURIs(rawurl string) error {
+	base, err := url.Parse(rawurl)
+	if err != nil {
+		return err
+	}
+	err = p.RewriteURIs(func(_ URIKind, uri string) (string, error) {
+		return relativizeURI(base, uri)
+	})
+	if err == nil {
+		p.BaseURL = base
+	}
+	return err
+}
+
+// SetBaseURL sets the URL of the media playlist itself. Relative URIs
+// of the playlist are resolved against it. The base URL is kept by
+// Decode so it may be set before or after decoding.
+func (p *MediaPlaylist) SetBaseURL(rawurl string) error {
+	base, err := url.Parse(rawurl)
+	if err != nil {
+		return err
+	}
+	p.BaseURL = base
+	return nil
+}
+
+// ResolveURIs rewrites URIs of media segments, keys and maps to the
+// absolute form using the base URL of the playlist. This operation
+// does reset playlist cache.
+func (p *MediaPlaylist) ResolveURIs() error {
+	if p.BaseURL == nil {
+		return ErrNoBaseURL
+	}
+	return p.RewriteURIs(func(_ URIKind, uri string) (string, error) {
+		return resolveURI(p.BaseURL, uri)
+	})
+}
+
+// RelativizeURIs rewrites absolute URIs of media segments, keys and
+// maps to paths relative to the new base URL and then sets it as the
+// base URL of the playlist. URIs pointed to other hosts are left as
+// is. This operation does reset playlist cache.
+func (p *MediaPlaylist) RelativizeURIs(rawurl string) error {
+	base, err := url.Parse(rawurl)
+	if err != nil {
+		return err
+	}
+	err = p.RewriteURIs(func(_ URIKind, uri string) (string, error) {
+		return relativizeURI(base, uri)
+	})
+	if err == nil {
+		p.BaseURL = base
+	}
+	return err
+}
+
+// RewriteURIs calls fn for the URI of each variant and alternative
+// rendition of the master playlist and replaces the URI with the
+// returned value. Renditions shared between variants are visited
+// once. Media playlists linked as variant chunklists are not
+// visited. URIs are replaced only when all calls succeed, the playlist
+// is left unchanged on error. This operation does reset playlist
+// cache.
+func (p *MasterPlaylist) RewriteURIs(fn URIRewriter) error {
+	var uris uriList
+	alts := make(map[*Alternative]bool)
+	for _, v := range p.Variants {
+		if v == nil {
+			continue
+		}
+		kind := URIVariant
+		if v.Iframe {
+			kind = URIIframe
+		}
+		uris.add(kind, &v.URI)
+		for _, alt := range v.Alternatives {
+			if alt == nil || alts[alt] {
+				continue
+			}
+			alts[alt] = true
+			uris.add(URIAlternative, &alt.URI)
+		}
+	}
+	defer p.buf.Reset()
+	return uris.rewrite(fn)
+}
+
+// RewriteURIs calls fn for the URI of the default key and map and for
+// the URIs of each segment in the playlist with their keys and maps
+// and replaces the URI with the returned value. Keys and maps shared
+// between segments are visited once. New URI-bearing tags supported
+// by the library should be visited here too. URIs are replaced only
+// when all calls succeed, the playlist is left unchanged on error.
+// This operation does reset playlist cache.
+func (p *MediaPlaylist) RewriteURIs(fn URIRewriter) error {
+	var uris uriList
+	keys := make(map[*Key]bool)
+	maps := make(map[*Map]bool)
+	addKey := func(key *Key) {
+		if key != nil && !keys[key] {
+			keys[key] = true
+			uris.add(URIKey, &key.URI)
+		}
+	}
+	addMap := func(xmap *Map) {
+		if xmap != nil && !maps[xmap] {
+			maps[xmap] = true
+			uris.add(URIMap, &xmap.URI)
+		}
+	}
+
+	addKey(p.Key)
+	for _, key := range p.Keys {
+		addKey(key)
+	}
+	addMap(p.Map)
+	head := p.head
+	for count := p.count; count > 0; count-- {
+		seg := p.Segments[head]
+		head = (head + 1) % p.capacity
+		if seg == nil {
+			continue
+		}
+		addKey(seg.Key)
+		for _, key := range seg.Keys {
+			addKey(key)
+		}
+		addMap(seg.Map)
+		uris.add(URISegment, &seg.URI)
+	}
+	defer p.buf.Reset()
+	return uris.rewrite(fn)
+}
+
+// uriList collects URIs of the playlist for rewriting.
+type uriList struct {
+	kinds []URIKind
+	uris  []*string
+}
+
+// add appends the non-empty URI to the list.
+func (l *uriList) add(kind URIKind, uri *string) {
+	if *uri != "" {
+		l.kinds = append(l.kinds, kind)
+		l.uris = append(l.uris, uri)
+	}
+}
+
+// rewrite calls fn for all URIs of the list and replaces them when all
+// calls succeed.
+func (l *uriList) rewrite(fn URIRewriter) error {
+	values := make([]string, len(l.uris))
+	for i, uri := range l.uris {
+		var err error
+		if values[i], err = fn(l.kinds[i], *uri); err != nil {
+			return err
+		}
+	}
+	for i, uri := range l.uris {
+		*uri = values[i]
+	}
+	return nil
+}
+
+// resolveURI returns the absolute form of the URI reference. Empty
+// references are kept empty (for example URI of the key with METHOD=NONE).
+func resolveURI(base *url.URL, uri string) (string, error) {
+	if uri == "" {
+		return uri, nil
+	}
+	ref, err := url.Parse(uri)
+	if err != nil {
+		return uri, err
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// relativizeURI returns the reference to the absolute URI relative to
+// the base. Relative URIs and URIs with another scheme, host or user
+// info are returned unchanged.
+func relativizeURI(base *url.URL, uri string) (string, error) {
+	if uri == "" {
+		return uri, nil
+	}
+	target, err := url.Parse(uri)
+	if err != nil {
+		return uri, err
+	}
+	if !target.IsAbs() || target.Opaque != "" ||
+		!strings.EqualFold(target.Scheme, base.Scheme) ||
+		!strings.EqualFold(target.Host, base.Host) ||
+		target.User.String() != base.User.String() {
+		return uri, nil
+	}
+
+	basePath := base.EscapedPath()
+	if basePath == "" {
+		basePath = "/"
+	}
+	targetPath := target.EscapedPath()
+	if targetPath == "" {
+		targetPath = "/"
+	}
+	// directories of the base, the last element is a file name
+	dirs := strings.Split(basePath, "/")
+	dirs = dirs[:len(dirs)-1]
+	parts := strings.Split(targetPath, "/")
+
+	var i int
+	for i < len(dirs) && i < len(parts)-1 && dirs[i] == parts[i] {
+		i++
+	}
+	rel := strings.Repeat("../", len(dirs)-i) + strings.Join(parts[i:], "/")
+	switch {
+	case rel == "":
+		rel = "./"
+	case strings.Contains(strings.SplitN(rel, "/", 2)[0], ":"):
+		// protect the first segment from interpretation as a scheme
+		rel = "./" + rel
+	}
+	if target.RawQuery != "" || target.ForceQuery {
+		rel += "?" + target.RawQuery
+	}
+	if target.Fragment != "" {
+		rel += "#" + target.EscapedFragment()
+	}
+	return rel, nil
+}
@@ -0,0 +1,278 @@
+/*
+ Playlist URI resolution tests.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bufio"
+	"errors"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestResolveURIsOfMediaPlaylist(t *testing.T) {
+	p, e := NewMediaPlaylist(3, 5)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	if e = p.ResolveURIs(); e != ErrNoBaseURL {
+		t.Errorf("Expected ErrNoBaseURL, got: %v", e)
+	}
+	p.SetDefaultKey("AES-128", "keys/default.key", "", "", "")
+	p.SetDefaultMap("init.mp4", 0, 0)
+	_ = p.Append("seg0.ts", 10, "")
+	_ = p.Append("../other/seg1.ts", 10, "")
+	_ = p.SetKey("NONE", "", "", "", "")
+	_ = p.Append("http://cdn.example.org/seg2.ts", 10, "")
+	_ = p.SetKey("AES-128", "/keys/2.key", "", "", "")
+	if e = p.SetBaseURL("http://example.com/live/stream/index.m3u8"); e != nil {
+		t.Fatal(e)
+	}
+	if e = p.ResolveURIs(); e != nil {
+		t.Fatal(e)
+	}
+	expected := []string{
+		"http://example.com/live/stream/seg0.ts",
+		"http://example.com/live/other/seg1.ts",
+		"http://cdn.example.org/seg2.ts",
+	}
+	for i, uri := range expected {
+		if p.Segments[i].URI != uri {
+			t.Errorf("Expected segment URI: %s, got: %s", uri, p.Segments[i].URI)
+		}
+	}
+	if p.Key.URI != "http://example.com/live/stream/keys/default.key" {
+		t.Errorf("Unexpected default key URI: %s", p.Key.URI)
+	}
+	if p.Map.URI != "http://example.com/live/stream/init.mp4" {
+		t.Errorf("Unexpected default map URI: %s", p.Map.URI)
+	}
+	if p.Segments[1].Key.URI != "" {
+		t.Errorf("Expected empty URI for METHOD=NONE, got: %s", p.Segments[1].Key.URI)
+	}
+	if p.Segments[2].Key.URI != "http://example.com/keys/2.key" {
+		t.Errorf("Unexpected segment key URI: %s", p.Segments[2].Key.URI)
+	}
+}
+
+func TestRelativizeURIsOfMediaPlaylist(t *testing.T) {
+	p, e := NewMediaPlaylist(3, 5)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	p.SetDefaultMap("https://origin.example.com/vod/init.mp4", 0, 0)
+	_ = p.Append("https://origin.example.com/vod/1080/seg0.ts?token=abc", 10, "")
+	_ = p.Append("https://origin.example.com/vod/seg1.ts", 10, "")
+	_ = p.Append("https://origin.example.com/ads/seg2.ts", 10, "")
+	_ = p.Append("https://cdn.example.com/vod/seg3.ts", 10, "")
+	if e = p.RelativizeURIs("https://origin.example.com/vod/index.m3u8"); e != nil {
+		t.Fatal(e)
+	}
+	expected := []string{
+		"1080/seg0.ts?token=abc",
+		"seg1.ts",
+		"../ads/seg2.ts",
+		"https://cdn.example.com/vod/seg3.ts",
+	}
+	for i, uri := range expected {
+		if p.Segments[i].URI != uri {
+			t.Errorf("Expected segment URI: %s, got: %s", uri, p.Segments[i].URI)
+		}
+	}
+	if p.Map.URI != "init.mp4" {
+		t.Errorf("Unexpected default map URI: %s", p.Map.URI)
+	}
+	if p.BaseURL == nil || p.BaseURL.String() != "https://origin.example.com/vod/index.m3u8" {
+		t.Errorf("Base URL was not updated: %v", p.BaseURL)
+	}
+	// round trip must restore original URIs
+	if e = p.ResolveURIs(); e != nil {
+		t.Fatal(e)
+	}
+	if p.Segments[2].URI != "https://origin.example.com/ads/seg2.ts" {
+		t.Errorf("Unexpected resolved URI: %s", p.Segments[2].URI)
+	}
+}
+
+func TestResolveURIsOfMasterPlaylist(t *testing.T) {
+	f, err := os.Open("sample-playlists/master-with-alternatives.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewMasterPlaylist()
+	if err = p.SetBaseURL("http://example.com/path/master.m3u8"); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.DecodeFrom(bufio.NewReader(f), false); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.ResolveURIs(); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range p.Variants {
+		if v.URI != "" && !strings.HasPrefix(v.URI, "http://example.com/path/") {
+			t.Errorf("Variant URI was not resolved: %s", v.URI)
+		}
+		for _, alt := range v.Alternatives {
+			if alt.URI != "" && !strings.HasPrefix(alt.URI, "http://example.com/path/") {
+				t.Errorf("Alternative URI was not resolved: %s", alt.URI)
+			}
+		}
+	}
+	if err = p.RelativizeURIs("http://example.com/path/master.m3u8"); err != nil {
+		t.Fatal(err)
+	}
+	if p.Variants[0].URI != "low/main/audio-video.m3u8" {
+		t.Errorf("Unexpected relative variant URI: %s", p.Variants[0].URI)
+	}
+}
+
+func TestRelativizeURI(t *testing.T) {
+	base, _ := url.Parse("http://example.com/a/b/index.m3u8")
+	cases := map[string]string{
+		"http://example.com/a/b/c.ts":     "c.ts",
+		"http://example.com/a/b/":         "./",
+		"http://example.com/a/c.ts":       "../c.ts",
+		"http://example.com/c.ts":         "../../c.ts",
+		"http://example.com/a/b/x:y.ts":   "./x:y.ts",
+		"http://example.com/a/b/c.ts#t=1": "c.ts#t=1",
+		"https://example.com/a/b/c.ts":    "https://example.com/a/b/c.ts",
+		"c.ts":                            "c.ts",
+		"":                                "",
+	}
+	for uri, expected := range cases {
+		rel, err := relativizeURI(base, uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rel != expected {
+			t.Errorf("Relativize %q: expected %q, got %q", uri, expected, rel)
+		}
+	}
+}
+
+func TestRewriteURIsOfMediaPlaylist(t *testing.T) {
+	p, e := NewMediaPlaylist(3, 5)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	p.SetDefaultKey("AES-128", "default.key", "", "", "")
+	p.SetDefaultMap("init.mp4", 0, 0)
+	key := &Key{Method: "AES-128", URI: "shared.key"}
+	for i := 0; i < 3; i++ {
+		_ = p.AppendSegment(&MediaSegment{URI: "seg.ts", Duration: 10, Key: key})
+	}
+	_ = p.SetKey("NONE", "", "", "", "")
+	_ = p.Encode()
+
+	kinds := make(map[URIKind]int)
+	e = p.RewriteURIs(func(kind URIKind, uri string) (string, error) {
+		kinds[kind]++
+		return uri + "?token=1", nil
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if kinds[URISegment] != 3 || kinds[URIKey] != 2 || kinds[URIMap] != 1 || len(kinds) != 3 {
+		t.Errorf("Unexpected number of visited URIs: %v", kinds)
+	}
+	if key.URI != "shared.key?token=1" {
+		t.Errorf("Shared key must be rewritten once, got: %s", key.URI)
+	}
+	if !strings.Contains(p.String(), "seg.ts?token=1\n") {
+		t.Errorf("Cache was not reset after rewriting:\n%s", p.String())
+	}
+
+	expected := errors.New("rewrite failed")
+	e = p.RewriteURIs(func(kind URIKind, uri string) (string, error) {
+		return uri, expected
+	})
+	if e != expected {
+		t.Errorf("Expected error from rewriter, got: %v", e)
+	}
+}
+
+func TestRewriteURIsOfMasterPlaylist(t *testing.T) {
+	f, err := os.Open("sample-playlists/master-with-i-frame-stream-inf.m3u8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewMasterPlaylist()
+	if err = p.DecodeFrom(bufio.NewReader(f), false); err != nil {
+		t.Fatal(err)
+	}
+	alt := &Alternative{Type: "AUDIO", GroupId: "aac", Name: "en", URI: "audio.m3u8"}
+	p.Variants[0].Alternatives = append(p.Variants[0].Alternatives, alt)
+	p.Variants[1].Alternatives = append(p.Variants[1].Alternatives, alt)
+
+	kinds := make(map[URIKind]int)
+	err = p.RewriteURIs(func(kind URIKind, uri string) (string, error) {
+		kinds[kind]++
+		return "https://cdn.example.com/" + uri, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kinds[URIAlternative] != 1 {
+		t.Errorf("Shared alternative must be visited once, got: %d", kinds[URIAlternative])
+	}
+	if kinds[URIVariant] == 0 || kinds[URIIframe] == 0 {
+		t.Errorf("Unexpected number of visited URIs: %v", kinds)
+	}
+	for _, v := range p.Variants {
+		if !strings.HasPrefix(v.URI, "https://cdn.example.com/") {
+			t.Errorf("Variant URI was not rewritten: %s", v.URI)
+		}
+	}
+
+	// nil variants are skipped, failed rewriting keeps URIs as is
+	before := variantURIs(p)
+	p.Variants = append(p.Variants, nil)
+	var calls int
+	expected := errors.New("rewrite failed")
+	err = p.RewriteURIs(func(kind URIKind, uri string) (string, error) {
+		if calls++; calls > 2 {
+			return "", expected
+		}
+		return "changed.m3u8", nil
+	})
+	if err != expected {
+		t.Errorf("Expected error from rewriter, got: %v", err)
+	}
+	if p.Variants = p.Variants[:len(p.Variants)-1]; variantURIs(p) != before {
+		t.Errorf("URIs changed after failed rewriting: %s", variantURIs(p))
+	}
+}
+
+func TestRewriteURIsOfMediaPlaylistOnError(t *testing.T) {
+	p, e := NewMediaPlaylist(0, 3)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	p.SetDefaultMap("init.mp4", 0, 0)
+	for i := 0; i < 3; i++ {
+		_ = p.Append("seg"+strconv.Itoa(i)+".ts", 10, "")
+	}
+	before := p.String()
+	err := p.RewriteURIs(func(kind URIKind, uri string) (string, error) {
+		if uri == "seg2.ts" {
+			return "", errors.New("rewrite failed")
+		}
+		return "changed.ts", nil
+	})
+	if err == nil {
+		t.Error("Expected error from rewriter")
+	}
+	if p.String() != before {
+		t.Errorf("Playlist changed after failed rewriting:\n%s", p)
+	}
+}
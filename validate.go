@@ -0,0 +1,168 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines validation of master playlists against the rules
+ of rendition groups.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationErrors lists all violations found in the playlist.
+type ValidationErrors []error
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// renditionGroup identifies a group of EXT-X-MEDIA renditions.
+type renditionGroup struct {
+	kind, id string
+}
+
+func (g renditionGroup) String() string {
+	return fmt.Sprintf("%s group %q", g.kind, g.id)
+}
+
+// Validate checks the master playlist against the rules of HLS
+// specification for variants and rendition groups:
+//
+//   - groups referenced by the variants are defined;
+//   - a group has at most one rendition with DEFAULT=YES;
+//   - names of renditions are unique within a group;
+//   - INSTREAM-ID is present for CLOSED-CAPTIONS only and URI is absent
+//     for them;
+//   - FORCED is present for SUBTITLES only;
+//   - CODECS of the variant with AUDIO group has an audio codec and
+//     lists the audio codecs of all variants referencing the group;
+//   - BANDWIDTH is not less than AVERAGE-BANDWIDTH.
+//
+// It returns ValidationErrors with all violations found or nil.
+func (p *MasterPlaylist) Validate() error {
+	var (
+		errs   ValidationErrors
+		groups = make(map[renditionGroup][]*Alternative)
+		order  []renditionGroup
+		seen   = make(map[*Alternative]bool)
+	)
+	for _, v := range p.Variants {
+		if v == nil {
+			continue
+		}
+		for _, alt := range v.Alternatives {
+			if alt == nil || seen[alt] {
+				continue
+			}
+			seen[alt] = true
+			g := renditionGroup{alt.Type, alt.GroupId}
+			if _, ok := groups[g]; !ok {
+				order = append(order, g)
+			}
+			groups[g] = append(groups[g], alt)
+		}
+	}
+
+	for _, g := range order {
+		var defaults int
+		names := make(map[string]bool)
+		for _, alt := range groups[g] {
+			if alt.Default {
+				defaults++
+			}
+			if names[alt.Name] {
+				errs = append(errs, fmt.Errorf("%s: duplicate NAME %q", g, alt.Name))
+			}
+			names[alt.Name] = true
+			if alt.Type == "CLOSED-CAPTIONS" {
+				if alt.InstreamId == "" {
+					errs = append(errs, fmt.Errorf("%s: INSTREAM-ID is required for %q", g, alt.Name))
+				}
+				if alt.URI != "" {
+					errs = append(errs, fmt.Errorf("%s: URI is not allowed for %q", g, alt.Name))
+				}
+			} else if alt.InstreamId != "" {
+				errs = append(errs, fmt.Errorf("%s: INSTREAM-ID is not allowed for %q", g, alt.Name))
+			}
+			if alt.Forced != "" && alt.Type != "SUBTITLES" {
+				errs = append(errs, fmt.Errorf("%s: FORCED is not allowed for %q", g, alt.Name))
+			}
+		}
+		if defaults > 1 {
+			errs = append(errs, fmt.Errorf("%s: %d renditions with DEFAULT=YES", g, defaults))
+		}
+	}
+
+	// audio codecs declared by the variants of each AUDIO group
+	audio := make(map[string][]string)
+	for _, v := range p.Variants {
+		if v == nil || v.Iframe || v.Audio == "" || v.Codecs == "" {
+			continue
+		}
+		for _, c := range variantAudioCodecs(v) {
+			if !containsString(audio[v.Audio], c) {
+				audio[v.Audio] = append(audio[v.Audio], c)
+			}
+		}
+	}
+
+	for _, v := range p.Variants {
+		if v == nil {
+			continue
+		}
+		for _, g := range []renditionGroup{{"VIDEO", v.Video}, {"AUDIO", v.Audio}, {"SUBTITLES", v.Subtitles}, {"CLOSED-CAPTIONS", v.Captions}} {
+			if g.id == "" || g.kind == "CLOSED-CAPTIONS" && g.id == "NONE" {
+				continue
+			}
+			if _, ok := groups[g]; !ok {
+				errs = append(errs, fmt.Errorf("variant %q: %s is not defined", v.URI, g))
+			}
+		}
+		if v.AverageBandwidth > v.Bandwidth {
+			errs = append(errs, fmt.Errorf("variant %q: BANDWIDTH %d is less than AVERAGE-BANDWIDTH %d", v.URI, v.Bandwidth, v.AverageBandwidth))
+		}
+		if v.Iframe || v.Audio == "" || v.Codecs == "" {
+			continue
+		}
+		codecs := variantAudioCodecs(v)
+		if len(codecs) == 0 {
+			errs = append(errs, fmt.Errorf("variant %q: CODECS lacks audio codecs of AUDIO group %q", v.URI, v.Audio))
+			continue
+		}
+		for _, c := range audio[v.Audio] {
+			if !containsString(codecs, c) {
+				errs = append(errs, fmt.Errorf("variant %q: CODECS lacks %q of AUDIO group %q", v.URI, c, v.Audio))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// variantAudioCodecs returns the audio entries of CODECS attribute of
+// the variant.
+func variantAudioCodecs(v *Variant) []string {
+	var list []string
+	codecs, _ := ParseCodecs(v.Codecs, false)
+	for _, c := range codecs {
+		if c.Kind() == MediaAudio {
+			list = append(list, c.String())
+		}
+	}
+	return list
+}
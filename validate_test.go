@@ -0,0 +1,98 @@
+/*
+ Master playlist validation tests.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func decodeValidateMaster(t *testing.T, s string) *MasterPlaylist {
+	p := NewMasterPlaylist()
+	if err := p.DecodeFrom(bytes.NewBufferString(s), true); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestValidateMasterPlaylist(t *testing.T) {
+	p := decodeValidateMaster(t, `#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",LANGUAGE="en",DEFAULT=YES,URI="en.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="Deutsch",LANGUAGE="de",DEFAULT=NO,URI="de.m3u8"
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME="English",LANGUAGE="en",DEFAULT=YES,FORCED=NO,URI="subs.m3u8"
+#EXT-X-MEDIA:TYPE=CLOSED-CAPTIONS,GROUP-ID="cc",NAME="English",LANGUAGE="en",INSTREAM-ID="CC1"
+#EXT-X-STREAM-INF:BANDWIDTH=1000000,AVERAGE-BANDWIDTH=900000,CODECS="avc1.4d401e,mp4a.40.2",AUDIO="aac",SUBTITLES="subs",CLOSED-CAPTIONS="cc"
+low.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=3000000,CODECS="avc1.4d401f,mp4a.40.2",AUDIO="aac",SUBTITLES="subs",CLOSED-CAPTIONS="cc"
+high.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=100000,CODECS="avc1.4d401e",URI="iframe.m3u8"
+`)
+	if err := p.Validate(); err != nil {
+		t.Errorf("Unexpected validation error: %s", err)
+	}
+}
+
+func TestValidateMasterPlaylistViolations(t *testing.T) {
+	p := decodeValidateMaster(t, `#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aud",NAME="English",LANGUAGE="en",DEFAULT=YES,URI="en.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aud",NAME="English",LANGUAGE="en",DEFAULT=YES,FORCED=YES,URI="en-ac3.m3u8"
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME="English",INSTREAM-ID="CC1",URI="subs.m3u8"
+#EXT-X-MEDIA:TYPE=CLOSED-CAPTIONS,GROUP-ID="cc",NAME="English",URI="cc.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=1000000,AVERAGE-BANDWIDTH=1200000,CODECS="avc1.4d401e,mp4a.40.2",AUDIO="aud",SUBTITLES="subs",CLOSED-CAPTIONS="cc"
+low.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=3000000,CODECS="avc1.4d401f,ac-3",AUDIO="aud"
+high.m3u8
+`)
+	err := p.Validate()
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+	for _, expected := range []string{
+		`AUDIO group "aud": duplicate NAME "English"`,
+		`AUDIO group "aud": FORCED is not allowed`,
+		`AUDIO group "aud": 2 renditions with DEFAULT=YES`,
+		`SUBTITLES group "subs": INSTREAM-ID is not allowed`,
+		`CLOSED-CAPTIONS group "cc": INSTREAM-ID is required`,
+		`CLOSED-CAPTIONS group "cc": URI is not allowed`,
+		`variant "low.m3u8": BANDWIDTH 1000000 is less than AVERAGE-BANDWIDTH 1200000`,
+		`variant "low.m3u8": CODECS lacks "ac-3" of AUDIO group "aud"`,
+		`variant "high.m3u8": CODECS lacks "mp4a.40.2" of AUDIO group "aud"`,
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Validation errors don't contain %q:\n%s", expected, err)
+		}
+	}
+	if len(errs) != 9 {
+		t.Errorf("Expected 9 errors, got %d: %s", len(errs), err)
+	}
+}
+
+func TestValidateMasterPlaylistUndefinedGroup(t *testing.T) {
+	m := NewMasterPlaylist()
+	m.Append("low.m3u8", nil, VariantParams{Bandwidth: 1000000, Audio: "aac", Captions: "NONE"})
+	err := m.Validate()
+	if err == nil || !strings.Contains(err.Error(), `AUDIO group "aac" is not defined`) {
+		t.Errorf("Unexpected validation error: %v", err)
+	}
+}
+
+func TestValidateMasterPlaylistVideoOnlyCodecs(t *testing.T) {
+	p := decodeValidateMaster(t, `#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="English",LANGUAGE="en",DEFAULT=YES,URI="en.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=1000000,CODECS="avc1.4d401f",AUDIO="aac"
+low.m3u8
+`)
+	err := p.Validate()
+	if err == nil || !strings.Contains(err.Error(), `variant "low.m3u8": CODECS lacks audio codecs of AUDIO group "aac"`) {
+		t.Errorf("Unexpected validation error: %v", err)
+	}
+}
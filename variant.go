@@ -0,0 +1,146 @@
+package m3u8
+
+/*
+ Part of M3U8 parser & generator library.
+ This file defines typed attributes of variants.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseResolution parses the value of RESOLUTION attribute in the form
+// of decimal width and height separated by "x".
+func ParseResolution(value string) (Resolution, error) {
+	parts := strings.Split(value, "x")
+	if len(parts) != 2 {
+		return Resolution{}, fmt.Errorf("invalid resolution %q", value)
+	}
+	width, err1 := strconv.ParseUint(parts[0], 10, 31)
+	height, err2 := strconv.ParseUint(parts[1], 10, 31)
+	if err1 != nil || err2 != nil || width == 0 || height == 0 {
+		return Resolution{}, fmt.Errorf("invalid resolution %q", value)
+	}
+	return Resolution{int(width), int(height)}, nil
+}
+
+func (r Resolution) String() string {
+	return fmt.Sprintf("%dx%d", r.Width, r.Height)
+}
+
+// Pixels returns the number of pixels of the frame.
+func (r Resolution) Pixels() int {
+	return r.Width * r.Height
+}
+
+// Valid reports whether the value is one of the defined video ranges.
+func (r VideoRange) Valid() bool {
+	switch r {
+	case VideoRangeSDR, VideoRangeHLG, VideoRangePQ:
+		return true
+	}
+	return false
+}
+
+// HDR reports whether the video range is a high dynamic range.
+func (r VideoRange) HDR() bool {
+	return r == VideoRangeHLG || r == VideoRangePQ
+}
+
+// Valid reports whether the value is one of the defined HDCP levels.
+func (l HDCPLevel) Valid() bool {
+	switch l {
+	case HDCPType0, HDCPType1, HDCPNone:
+		return true
+	}
+	return false
+}
+
+// ResolutionValue returns the parsed RESOLUTION attribute, zero
+// resolution if the attribute is absent.
+func (vp *VariantParams) ResolutionValue() (Resolution, error) {
+	if vp.Resolution == "" {
+		return Resolution{}, nil
+	}
+	return ParseResolution(vp.Resolution)
+}
+
+// SetResolution sets RESOLUTION attribute.
+func (vp *VariantParams) SetResolution(width, height int) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("invalid resolution %dx%d", width, height)
+	}
+	vp.Resolution = Resolution{width, height}.String()
+	return nil
+}
+
+// VideoRangeValue returns VIDEO-RANGE attribute, empty value if the
+// attribute is absent.
+func (vp *VariantParams) VideoRangeValue() (VideoRange, error) {
+	r := VideoRange(vp.VideoRange)
+	if r != "" && !r.Valid() {
+		return r, fmt.Errorf("invalid video range %q", vp.VideoRange)
+	}
+	return r, nil
+}
+
+// SetVideoRange sets VIDEO-RANGE attribute, empty value removes it.
+func (vp *VariantParams) SetVideoRange(r VideoRange) error {
+	if r != "" && !r.Valid() {
+		return fmt.Errorf("invalid video range %q", r)
+	}
+	vp.VideoRange = string(r)
+	return nil
+}
+
+// HDCPLevelValue returns HDCP-LEVEL attribute, empty value if the
+// attribute is absent.
+func (vp *VariantParams) HDCPLevelValue() (HDCPLevel, error) {
+	l := HDCPLevel(vp.HDCPLevel)
+	if l != "" && !l.Valid() {
+		return l, fmt.Errorf("invalid HDCP level %q", vp.HDCPLevel)
+	}
+	return l, nil
+}
+
+// SetHDCPLevel sets HDCP-LEVEL attribute, empty value removes it.
+func (vp *VariantParams) SetHDCPLevel(l HDCPLevel) error {
+	if l != "" && !l.Valid() {
+		return fmt.Errorf("invalid HDCP level %q", l)
+	}
+	vp.HDCPLevel = string(l)
+	return nil
+}
+
+// SetFrameRate sets FRAME-RATE attribute rounded to three decimal
+// places as it is written to the playlist. Zero removes it.
+func (vp *VariantParams) SetFrameRate(fps float64) error {
+	if fps < 0 || math.IsNaN(fps) || math.IsInf(fps, 0) {
+		return fmt.Errorf("invalid frame rate %v", fps)
+	}
+	vp.FrameRate = math.Round(fps*1000) / 1000
+	return nil
+}
+
+// formatFrameRate returns the value of FRAME-RATE attribute.
+func formatFrameRate(fps float64) string {
+	return strconv.FormatFloat(fps, 'f', 3, 64)
+}
+
+// parseFrameRate parses the value of FRAME-RATE attribute.
+func parseFrameRate(value string) (float64, error) {
+	fps, err := strconv.ParseFloat(value, 64)
+	if err != nil || !(fps > 0) || math.IsInf(fps, 0) || strings.ContainsAny(value, "eE+-") {
+		return 0, fmt.Errorf("invalid frame rate %q", value)
+	}
+	return fps, nil
+}
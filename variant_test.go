@@ -0,0 +1,86 @@
+/*
+ Typed variant attributes tests.
+
+ Copyright 2013-2019 The Project Developers.
+ See the AUTHORS and LICENSE files at the top-level directory of this distribution
+ and at https://github.com/grafov/m3u8/
+
+ ॐ तारे तुत्तारे तुरे स्व
+*/
+package m3u8
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestVariantParamsTypedAttributes(t *testing.T) {
+	var vp VariantParams
+	if e := vp.SetResolution(1920, 1080); e != nil {
+		t.Fatal(e)
+	}
+	if vp.Resolution != "1920x1080" {
+		t.Errorf("Unexpected resolution: %s", vp.Resolution)
+	}
+	if r, e := vp.ResolutionValue(); e != nil || r != (Resolution{1920, 1080}) || r.Pixels() != 1920*1080 {
+		t.Errorf("Unexpected resolution: %+v, %v", r, e)
+	}
+	if e := vp.SetResolution(0, 1080); e == nil {
+		t.Error("Expected error for zero width")
+	}
+	if e := vp.SetVideoRange(VideoRangePQ); e != nil || vp.VideoRange != "PQ" {
+		t.Errorf("Unexpected video range: %s, %v", vp.VideoRange, e)
+	}
+	if r, _ := vp.VideoRangeValue(); !r.HDR() {
+		t.Error("Expected PQ to be HDR")
+	}
+	if e := vp.SetVideoRange("HDR10"); e == nil {
+		t.Error("Expected error for invalid video range")
+	}
+	if e := vp.SetHDCPLevel(HDCPType1); e != nil || vp.HDCPLevel != "TYPE-1" {
+		t.Errorf("Unexpected HDCP level: %s, %v", vp.HDCPLevel, e)
+	}
+	vp.HDCPLevel = "TYPE-2"
+	if _, e := vp.HDCPLevelValue(); e == nil {
+		t.Error("Expected error for invalid HDCP level")
+	}
+	if e := vp.SetFrameRate(30000.0 / 1001); e != nil || vp.FrameRate != 29.97 {
+		t.Errorf("Unexpected frame rate: %v, %v", vp.FrameRate, e)
+	}
+}
+
+func TestEncodeMasterFrameRate(t *testing.T) {
+	m := NewMasterPlaylist()
+	p, _ := NewMediaPlaylist(1, 1)
+	vp := VariantParams{Bandwidth: 1000000}
+	_ = vp.SetFrameRate(25)
+	m.Append("a.m3u8", p, vp)
+	vp.FrameRate = 30000.0 / 1001
+	m.Append("b.m3u8", p, vp)
+	out := m.String()
+	if !strings.Contains(out, "FRAME-RATE=25.000\n") || !strings.Contains(out, "FRAME-RATE=29.970\n") {
+		t.Errorf("Expected frame rates with three decimal places:\n%s", out)
+	}
+}
+
+func TestDecodeMasterInvalidTypedAttributes(t *testing.T) {
+	for _, attr := range []string{
+		"RESOLUTION=1920X1080",
+		"RESOLUTION=1920x",
+		"VIDEO-RANGE=HDR",
+		"HDCP-LEVEL=TYPE-2",
+		"FRAME-RATE=-25",
+		"FRAME-RATE=fast",
+	} {
+		playlist := "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1000000," + attr + "\nchunklist.m3u8\n"
+		m := NewMasterPlaylist()
+		if e := m.DecodeFrom(bytes.NewBufferString(playlist), true); e == nil {
+			t.Errorf("Expected error for %s in strict mode", attr)
+		}
+		m = NewMasterPlaylist()
+		if e := m.DecodeFrom(bytes.NewBufferString(playlist), false); e != nil || len(m.Variants) != 1 {
+			t.Errorf("Unexpected error for %s in non-strict mode: %v", attr, e)
+		}
+	}
+}
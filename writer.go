@@ -50,6 +50,9 @@ func (p *MasterPlaylist) Append(uri string, chunklist *MediaPlaylist, params Var
 	v.URI = uri
 	v.Chunklist = chunklist
 	v.VariantParams = params
+	if v.Codecs == "" && len(v.CodecList) > 0 {
+		v.Codecs = FormatCodecs(v.CodecList)
+	}
 	p.Variants = append(p.Variants, v)
 	if len(v.Alternatives) > 0 {
 		// From section 7:
@@ -152,7 +155,7 @@ func (p *MasterPlaylist) Encode() *bytes.Buffer {
 				}
 				if alt.InstreamId != "" {
 					p.buf.WriteString(",INSTREAM-ID=\"")
-					p.buf.WriteString(alt.Channels)
+					p.buf.WriteString(alt.InstreamId)
 					p.buf.WriteRune('"')
 				}
 				if alt.URI != "" {
@@ -250,7 +253,7 @@ func (p *MasterPlaylist) Encode() *bytes.Buffer {
 			}
 			if pl.FrameRate != 0 {
 				p.buf.WriteString(",FRAME-RATE=")
-				p.buf.WriteString(strconv.FormatFloat(pl.FrameRate, 'f', 3, 64))
+				p.buf.WriteString(formatFrameRate(pl.FrameRate))
 			}
 			if pl.VideoRange != "" {
 				p.buf.WriteString(",VIDEO-RANGE=")
@@ -340,15 +343,37 @@ func (p *MediaPlaylist) last() uint {
 }
 
 // Remove current segment from the head of chunk slice form a media playlist. Useful for sliding playlists.
+// Media sequence and discontinuity sequence of live playlists are advanced accordingly.
 // This operation does reset playlist cache.
 func (p *MediaPlaylist) Remove() (err error) {
 	if p.count == 0 {
 		return errors.New("playlist is empty")
 	}
+	removed := p.Segments[p.head]
 	p.head = (p.head + 1) % p.capacity
 	p.count--
+	// the key and the map of the removed segment remain in force for
+	// the next one, they become the defaults of the playlist
+	if next := p.Segments[p.head]; p.count > 0 && removed != nil && next != nil {
+		if next.Key == nil {
+			next.Key, next.Keys = removed.Key, removed.Keys
+		}
+		if next.Map == nil {
+			next.Map = removed.Map
+		}
+		if next.Key != nil {
+			p.Key, p.Keys = next.Key, next.Keys
+		}
+		if next.Map != nil {
+			p.Map = next.Map
+		}
+	}
 	if !p.Closed {
 		p.SeqNo++
+		// EXT-X-DISCONTINUITY leaves the playlist with the segment
+		if removed != nil && removed.Discontinuity {
+			p.DiscontinuitySeq++
+		}
 	}
 	p.buf.Reset()
 	return nil
@@ -374,11 +399,19 @@ func (p *MediaPlaylist) AppendSegment(seg *MediaSegment) error {
 	if p.count > 0 {
 		seg.SeqId = p.Segments[(p.capacity+p.tail-1)%p.capacity].SeqId + 1
 	}
+	if p.rotation != nil {
+		if err := p.rotation.apply(p, seg); err != nil {
+			return err
+		}
+	}
 	p.Segments[p.tail] = seg
 	p.tail = (p.tail + 1) % p.capacity
 	p.count++
 	if p.TargetDuration < seg.Duration {
-		p.TargetDuration = math.Ceil(seg.Duration)
+		p.TargetDuration = p.roundDuration(seg.Duration)
+	}
+	if p.autoPDT != nil {
+		p.autoPDT.apply(seg)
 	}
 	p.buf.Reset()
 	return nil
@@ -422,33 +455,19 @@ func (p *MediaPlaylist) Encode() *bytes.Buffer {
 		}
 	}
 
+	// default key and map are not written when the first segment
+	// overrides them
+	var first *MediaSegment
+	if window := p.Window(); len(window) > 0 {
+		first = window[0]
+	}
+	defaultKey := p.Key != nil && (first == nil || first.Key == nil || sameKeySet(p.Key, p.Keys, first.Key, first.Keys))
+	defaultMap := p.Map != nil && (first == nil || first.Map == nil || *first.Map == *p.Map)
 	// default key (workaround for Widevine)
-	if p.Key != nil {
-		p.buf.WriteString("#EXT-X-KEY:")
-		p.buf.WriteString("METHOD=")
-		p.buf.WriteString(p.Key.Method)
-		if p.Key.Method != "NONE" {
-			p.buf.WriteString(",URI=\"")
-			p.buf.WriteString(p.Key.URI)
-			p.buf.WriteRune('"')
-			if p.Key.IV != "" {
-				p.buf.WriteString(",IV=")
-				p.buf.WriteString(p.Key.IV)
-			}
-			if p.Key.Keyformat != "" {
-				p.buf.WriteString(",KEYFORMAT=\"")
-				p.buf.WriteString(p.Key.Keyformat)
-				p.buf.WriteRune('"')
-			}
-			if p.Key.Keyformatversions != "" {
-				p.buf.WriteString(",KEYFORMATVERSIONS=\"")
-				p.buf.WriteString(p.Key.Keyformatversions)
-				p.buf.WriteRune('"')
-			}
-		}
-		p.buf.WriteRune('\n')
+	if defaultKey {
+		p.writeKeys(p.Key, p.Keys)
 	}
-	if p.Map != nil {
+	if defaultMap {
 		p.buf.WriteString("#EXT-X-MAP:")
 		p.buf.WriteString("URI=\"")
 		p.buf.WriteString(p.Map.URI)
@@ -567,34 +586,30 @@ func (p *MediaPlaylist) Encode() *bytes.Buffer {
 		durationCache = make(map[float64]string)
 	)
 
-	head := p.head
-	count := p.count
-	for i := uint(0); (i < p.winsize || p.winsize == 0) && count > 0; count-- {
-		seg = p.Segments[head]
-		head = (head + 1) % p.capacity
-		if seg == nil { // protection from badly filled chunklists
-			continue
-		}
-		if p.winsize > 0 { // skip for VOD playlists, where winsize = 0
-			i++
-		}
+	// key and map in force for the segment, they are emitted only when changed
+	var (
+		key  *Key
+		keys []*Key
+		xmap *Map
+	)
+	if defaultKey {
+		key, keys = p.Key, p.Keys
+	}
+	if defaultMap {
+		xmap = p.Map
+	}
+	// wall-clock times of the segments and of the written cue-outs by
+	// their IDs for START-DATE of EXT-X-DATERANGE
+	var (
+		startDates map[*MediaSegment]time.Time
+		outDates   map[string]time.Time
+		lastOut    string // ID of the cue-out of the current break
+	)
+	for _, seg = range p.Window() {
 		if seg.SCTE != nil {
 			switch seg.SCTE.Syntax {
 			case SCTE35_67_2014:
-				p.buf.WriteString("#EXT-SCTE35:")
-				p.buf.WriteString("CUE=\"")
-				p.buf.WriteString(seg.SCTE.Cue)
-				p.buf.WriteRune('"')
-				if seg.SCTE.ID != "" {
-					p.buf.WriteString(",ID=\"")
-					p.buf.WriteString(seg.SCTE.ID)
-					p.buf.WriteRune('"')
-				}
-				if seg.SCTE.Time != 0 {
-					p.buf.WriteString(",TIME=")
-					p.buf.WriteString(strconv.FormatFloat(seg.SCTE.Time, 'f', -1, 64))
-				}
-				p.buf.WriteRune('\n')
+				p.writeSCTE67(seg.SCTE)
 			case SCTE35_OATCLS:
 				switch seg.SCTE.CueType {
 				case SCTE35Cue_Start:
@@ -617,39 +632,151 @@ func (p *MediaPlaylist) Encode() *bytes.Buffer {
 					p.buf.WriteString("#EXT-X-CUE-IN")
 					p.buf.WriteRune('\n')
 				}
-			}
-		}
-		// check for key change
-		if seg.Key != nil && p.Key != seg.Key {
-			p.buf.WriteString("#EXT-X-KEY:")
-			p.buf.WriteString("METHOD=")
-			p.buf.WriteString(seg.Key.Method)
-			if seg.Key.Method != "NONE" {
-				p.buf.WriteString(",URI=\"")
-				p.buf.WriteString(seg.Key.URI)
-				p.buf.WriteRune('"')
-				if seg.Key.IV != "" {
-					p.buf.WriteString(",IV=")
-					p.buf.WriteString(seg.Key.IV)
+			case SCTE35_ELEMENTAL:
+				switch seg.SCTE.CueType {
+				case SCTE35Cue_Start:
+					p.buf.WriteString("#EXT-X-CUE-OUT")
+					if seg.SCTE.Time != 0 {
+						p.buf.WriteString(":DURATION=")
+						p.buf.WriteString(strconv.FormatFloat(seg.SCTE.Time, 'f', -1, 64))
+					}
+					p.buf.WriteRune('\n')
+				case SCTE35Cue_Mid:
+					p.buf.WriteString("#EXT-X-CUE-OUT-CONT:")
+					p.buf.WriteString(strconv.FormatFloat(seg.SCTE.Elapsed, 'f', -1, 64))
+					p.buf.WriteRune('/')
+					p.buf.WriteString(strconv.FormatFloat(seg.SCTE.Time, 'f', -1, 64))
+					p.buf.WriteRune('\n')
+				case SCTE35Cue_End:
+					p.buf.WriteString("#EXT-X-CUE-IN")
+					p.buf.WriteRune('\n')
 				}
-				if seg.Key.Keyformat != "" {
-					p.buf.WriteString(",KEYFORMAT=\"")
-					p.buf.WriteString(seg.Key.Keyformat)
+			case SCTE35_ANVATO:
+				switch seg.SCTE.CueType {
+				case SCTE35Cue_Start:
+					p.buf.WriteString("#EXT-X-CUE-OUT:")
+					p.buf.WriteString(strconv.FormatFloat(seg.SCTE.Time, 'f', -1, 64))
+					p.buf.WriteRune('\n')
+				case SCTE35Cue_Mid:
+					p.buf.WriteString("#EXT-X-CUE-OUT-CONT:")
+					p.buf.WriteString("ELAPSEDTIME=")
+					p.buf.WriteString(strconv.FormatFloat(seg.SCTE.Elapsed, 'f', -1, 64))
+					p.buf.WriteString(",DURATION=")
+					p.buf.WriteString(strconv.FormatFloat(seg.SCTE.Time, 'f', -1, 64))
+					if seg.SCTE.Cue != "" {
+						p.buf.WriteString(",SCTE35=")
+						p.buf.WriteString(seg.SCTE.Cue)
+					}
+					p.buf.WriteRune('\n')
+				case SCTE35Cue_End:
+					p.buf.WriteString("#EXT-X-CUE-IN")
+					p.buf.WriteRune('\n')
+				}
+			case SCTE35_ADOBE:
+				// there is no tag for the segments inside of the break
+				if seg.SCTE.CueType == SCTE35Cue_Mid {
+					break
+				}
+				p.buf.WriteString("#EXT-X-CUE:TYPE=")
+				if seg.SCTE.CueType == SCTE35Cue_End {
+					p.buf.WriteString("\"SpliceIn\"")
+				} else {
+					p.buf.WriteString("\"SpliceOut\"")
+				}
+				if seg.SCTE.ID != "" {
+					p.buf.WriteString(",ID=\"")
+					p.buf.WriteString(seg.SCTE.ID)
 					p.buf.WriteRune('"')
 				}
-				if seg.Key.Keyformatversions != "" {
-					p.buf.WriteString(",KEYFORMATVERSIONS=\"")
-					p.buf.WriteString(seg.Key.Keyformatversions)
+				if seg.SCTE.Time != 0 {
+					p.buf.WriteString(",DURATION=")
+					p.buf.WriteString(strconv.FormatFloat(seg.SCTE.Time, 'f', -1, 64))
+				}
+				if seg.SCTE.Cue != "" {
+					p.buf.WriteString(",CUE=\"")
+					p.buf.WriteString(seg.SCTE.Cue)
 					p.buf.WriteRune('"')
 				}
+				p.buf.WriteRune('\n')
+			case SCTE35_SPLICEPOINT:
+				if seg.SCTE.CueType == SCTE35Cue_Mid {
+					break
+				}
+				p.buf.WriteString("#EXT-X-SPLICEPOINT-SCTE35:")
+				p.buf.WriteString(seg.SCTE.Cue)
+				p.buf.WriteRune('\n')
+			case SCTE35_DATERANGE:
+				if seg.SCTE.CueType == SCTE35Cue_Mid {
+					break
+				}
+				if startDates == nil {
+					startDates = make(map[*MediaSegment]time.Time)
+					for _, e := range p.Timeline() {
+						startDates[e.Segment] = e.ProgramDateTime
+					}
+				}
+				id, start := seg.SCTE.ID, startDates[seg]
+				if seg.SCTE.CueType == SCTE35Cue_End {
+					// the date range of the cue-in repeats ID and
+					// START-DATE of its cue-out
+					if id == "" {
+						id = lastOut
+					}
+					if date, ok := outDates[id]; ok {
+						start = date
+					} else if !start.IsZero() {
+						start = start.Add(-time.Duration(seg.SCTE.Time * float64(time.Second)))
+					}
+					lastOut = ""
+				}
+				if start.IsZero() {
+					// START-DATE is mandatory, without PROGRAM-DATE-TIME
+					// the cue is written in the syntax without dates
+					p.writeSCTE67(seg.SCTE)
+					break
+				}
+				if id == "" {
+					id = fmt.Sprintf("splice-%d", seg.SeqId)
+				}
+				p.buf.WriteString("#EXT-X-DATERANGE:ID=\"")
+				p.buf.WriteString(id)
+				p.buf.WriteString("\",START-DATE=\"")
+				p.buf.WriteString(start.Format(DATETIME))
+				p.buf.WriteRune('"')
+				if seg.SCTE.CueType == SCTE35Cue_End {
+					if seg.SCTE.Time != 0 {
+						p.buf.WriteString(",DURATION=")
+						p.buf.WriteString(strconv.FormatFloat(seg.SCTE.Time, 'f', -1, 64))
+					}
+					p.buf.WriteString(",SCTE35-IN=")
+					p.buf.WriteString(seg.SCTE.Cue)
+					p.buf.WriteRune('\n')
+					break
+				}
+				if outDates == nil {
+					outDates = make(map[string]time.Time)
+				}
+				outDates[id], lastOut = start, id
+				if seg.SCTE.Time != 0 {
+					p.buf.WriteString(",PLANNED-DURATION=")
+					p.buf.WriteString(strconv.FormatFloat(seg.SCTE.Time, 'f', -1, 64))
+				}
+				p.buf.WriteString(",SCTE35-OUT=")
+				p.buf.WriteString(seg.SCTE.Cue)
+				p.buf.WriteRune('\n')
 			}
-			p.buf.WriteRune('\n')
+		}
+		// check for key change, the set of keys is emitted as a whole
+		if seg.Key != nil && !sameKeySet(key, keys, seg.Key, seg.Keys) {
+			key, keys = seg.Key, seg.Keys
+			p.writeKeys(seg.Key, seg.Keys)
 		}
 		if seg.Discontinuity {
 			p.buf.WriteString("#EXT-X-DISCONTINUITY\n")
 		}
-		// ignore segment Map if default playlist Map is present
-		if p.Map == nil && seg.Map != nil {
+		// check for map change
+		if seg.Map != nil && (xmap == nil || *xmap != *seg.Map) {
+			xmap = seg.Map
 			p.buf.WriteString("#EXT-X-MAP:")
 			p.buf.WriteString("URI=\"")
 			p.buf.WriteString(seg.Map.URI)
@@ -714,6 +841,39 @@ func (p *MediaPlaylist) Encode() *bytes.Buffer {
 	return &p.buf
 }
 
+// writeKeys writes EXT-X-KEY tags of the key set. Other keys of the
+// set are ignored for METHOD=NONE which clears the set.
+func (p *MediaPlaylist) writeKeys(key *Key, keys []*Key) {
+	for i, k := range append([]*Key{key}, keys...) {
+		if k == nil || i > 0 && key.Method == "NONE" {
+			continue
+		}
+		p.buf.WriteString("#EXT-X-KEY:")
+		p.buf.WriteString("METHOD=")
+		p.buf.WriteString(k.Method)
+		if k.Method != "NONE" {
+			p.buf.WriteString(",URI=\"")
+			p.buf.WriteString(k.URI)
+			p.buf.WriteRune('"')
+			if k.IV != "" {
+				p.buf.WriteString(",IV=")
+				p.buf.WriteString(k.IV)
+			}
+			if k.Keyformat != "" {
+				p.buf.WriteString(",KEYFORMAT=\"")
+				p.buf.WriteString(k.Keyformat)
+				p.buf.WriteRune('"')
+			}
+			if k.Keyformatversions != "" {
+				p.buf.WriteString(",KEYFORMATVERSIONS=\"")
+				p.buf.WriteString(k.Keyformatversions)
+				p.buf.WriteRune('"')
+			}
+		}
+		p.buf.WriteRune('\n')
+	}
+}
+
 // String here for compatibility with Stringer interface For example
 // fmt.Printf("%s", sampleMediaList) will encode playist and print its
 // string representation.
@@ -721,6 +881,89 @@ func (p *MediaPlaylist) String() string {
 	return p.Encode().String()
 }
 
+// SetTargetDurationRounding sets the rounding of segment durations used
+// for EXT-X-TARGETDURATION by AppendSegment, RecomputeTargetDuration
+// and CheckTargetDuration.
+func (p *MediaPlaylist) SetTargetDurationRounding(rounding TargetDurationRounding) {
+	p.targetRounding = rounding
+}
+
+// RecomputeTargetDuration sets the target duration to the maximum of
+// rounded durations of the segments in the current window. Unlike
+// AppendSegment it may lower the target when long segments left the
+// window. It returns the new target duration.
+func (p *MediaPlaylist) RecomputeTargetDuration() float64 {
+	var target float64
+	for _, seg := range p.Window() {
+		if d := p.roundDuration(seg.Duration); d > target {
+			target = d
+		}
+	}
+	p.TargetDuration = target
+	p.buf.Reset()
+	return target
+}
+
+// CheckTargetDuration reports segments of the current window which
+// rounded durations exceed the target duration of the playlist. The
+// returned error is *TargetDurationError.
+func (p *MediaPlaylist) CheckTargetDuration() error {
+	var exceeding []*MediaSegment
+	target := math.Ceil(p.TargetDuration)
+	for _, seg := range p.Window() {
+		if p.roundDuration(seg.Duration) > target {
+			exceeding = append(exceeding, seg)
+		}
+	}
+	if len(exceeding) > 0 {
+		return &TargetDurationError{TargetDuration: target, Segments: exceeding}
+	}
+	return nil
+}
+
+// TargetDurationError describes segments which durations exceed the
+// target duration of the playlist.
+type TargetDurationError struct {
+	TargetDuration float64
+	Segments       []*MediaSegment
+}
+
+func (e *TargetDurationError) Error() string {
+	ids := make([]string, len(e.Segments))
+	for i, seg := range e.Segments {
+		ids[i] = strconv.FormatUint(seg.SeqId, 10)
+	}
+	return fmt.Sprintf("segments %s exceed target duration %v", strings.Join(ids, ", "), e.TargetDuration)
+}
+
+// writeSCTE67 writes the cue in SCTE35_67_2014 syntax.
+func (p *MediaPlaylist) writeSCTE67(scte *SCTE) {
+	p.buf.WriteString("#EXT-SCTE35:")
+	p.buf.WriteString("CUE=\"")
+	p.buf.WriteString(scte.Cue)
+	p.buf.WriteRune('"')
+	if scte.ID != "" {
+		p.buf.WriteString(",ID=\"")
+		p.buf.WriteString(scte.ID)
+		p.buf.WriteRune('"')
+	}
+	if scte.Time != 0 {
+		p.buf.WriteString(",TIME=")
+		p.buf.WriteString(strconv.FormatFloat(scte.Time, 'f', -1, 64))
+	}
+	p.buf.WriteRune('\n')
+}
+
+// roundDuration returns the duration rounded for EXT-X-TARGETDURATION,
+// the target duration of a playlist with segments is at least 1.
+func (p *MediaPlaylist) roundDuration(duration float64) float64 {
+	rounded := math.Ceil(duration)
+	if p.targetRounding == TargetDurationRound {
+		rounded = math.Round(duration)
+	}
+	return math.Max(rounded, 1)
+}
+
 // DurationAsInt represents the duration as the integer in encoded playlist.
 func (p *MediaPlaylist) DurationAsInt(yes bool) {
 	if yes {
@@ -755,13 +998,29 @@ func (p *MediaPlaylist) SetDefaultKey(method, uri, iv, keyformat, keyformatversi
 		version(&p.ver, 5)
 	}
 	p.Key = &Key{method, uri, iv, keyformat, keyformatversions}
+	p.Keys = nil
 
 	return nil
 }
 
+// SetDefaultKeys sets the set of encryption keys appeared once in
+// header of the playlist. Keys of the set are for different KEYFORMATs
+// (multi-DRM), the first one goes to MediaPlaylist.Key and others to
+// MediaPlaylist.Keys.
+func (p *MediaPlaylist) SetDefaultKeys(keys ...*Key) error {
+	if err := checkKeySet(keys); err != nil {
+		return err
+	}
+	if hasKeyformat(keys) {
+		version(&p.ver, 5)
+	}
+	p.Key, p.Keys = keys[0], append([]*Key(nil), keys[1:]...)
+	return nil
+}
+
 // SetDefaultMap sets default Media Initialization Section values for
 // playlist (pointer to MediaPlaylist.Map). Set EXT-X-MAP tag for the
-// whole playlist.
+// whole playlist, segments with own different map override it.
 func (p *MediaPlaylist) SetDefaultMap(uri string, limit, offset int64) {
 	version(&p.ver, 5) // due section 4
 	p.Map = &Map{uri, limit, offset}
@@ -788,10 +1047,54 @@ func (p *MediaPlaylist) SetKey(method, uri, iv, keyformat, keyformatversions str
 		version(&p.ver, 5)
 	}
 
-	p.Segments[p.last()].Key = &Key{method, uri, iv, keyformat, keyformatversions}
+	seg := p.Segments[p.last()]
+	seg.Key, seg.Keys = &Key{method, uri, iv, keyformat, keyformatversions}, nil
 	return nil
 }
 
+// SetKeys sets the set of encryption keys for the current segment of
+// media playlist. Keys of the set are for different KEYFORMATs
+// (multi-DRM), the first one goes to Segment.Key and others to
+// Segment.Keys.
+func (p *MediaPlaylist) SetKeys(keys ...*Key) error {
+	if p.count == 0 {
+		return errors.New("playlist is empty")
+	}
+	if err := checkKeySet(keys); err != nil {
+		return err
+	}
+	if hasKeyformat(keys) {
+		version(&p.ver, 5)
+	}
+	seg := p.Segments[p.last()]
+	seg.Key, seg.Keys = keys[0], append([]*Key(nil), keys[1:]...)
+	return nil
+}
+
+// checkKeySet checks that the set has keys and all of them are set.
+func checkKeySet(keys []*Key) error {
+	if len(keys) == 0 {
+		return errors.New("no keys in the set")
+	}
+	for i, k := range keys {
+		if k == nil {
+			return fmt.Errorf("key %d of the set is nil", i)
+		}
+	}
+	return nil
+}
+
+// hasKeyformat reports whether any key of the set has KEYFORMAT or
+// KEYFORMATVERSIONS attributes.
+func hasKeyformat(keys []*Key) bool {
+	for _, k := range keys {
+		if k.Keyformat != "" || k.Keyformatversions != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // SetMap sets map for the current segment of media playlist (pointer
 // to Segment.Map).
 func (p *MediaPlaylist) SetMap(uri string, limit, offset int64) error {
@@ -840,7 +1143,11 @@ func (p *MediaPlaylist) SetDiscontinuity() error {
 	if p.count == 0 {
 		return errors.New("playlist is empty")
 	}
-	p.Segments[p.last()].Discontinuity = true
+	seg := p.Segments[p.last()]
+	seg.Discontinuity = true
+	if p.autoPDT != nil {
+		p.autoPDT.discontinuity(seg)
+	}
 	return nil
 }
 
@@ -857,6 +1164,111 @@ func (p *MediaPlaylist) SetProgramDateTime(value time.Time) error {
 	return nil
 }
 
+// SetAutoProgramDateTime enables automatic EXT-X-PROGRAM-DATE-TIME for
+// the segments appended after the call. Segments which already have
+// ProgramDateTime keep it and next wall-clock times are counted from
+// it. Nil value disables automatic mode.
+func (p *MediaPlaylist) SetAutoProgramDateTime(mode *AutoProgramDateTime) {
+	if mode == nil {
+		p.autoPDT = nil
+		return
+	}
+	p.autoPDT = &autoProgramDateTime{mode: *mode, next: mode.Start}
+	if p.autoPDT.mode.Every == 0 {
+		p.autoPDT.mode.Every = 1
+	}
+}
+
+// autoProgramDateTime keeps the state of automatic
+// EXT-X-PROGRAM-DATE-TIME generation.
+type autoProgramDateTime struct {
+	mode AutoProgramDateTime
+	next time.Time // wall-clock time of the next segment
+	n    uint      // number of segments since the last tagged one
+}
+
+func (a *autoProgramDateTime) apply(seg *MediaSegment) {
+	if seg.Discontinuity && a.mode.ResetOnDiscontinuity {
+		a.n = 0
+	}
+	if !seg.ProgramDateTime.IsZero() {
+		a.next, a.n = seg.ProgramDateTime, 0
+	} else if a.n%a.mode.Every == 0 {
+		seg.ProgramDateTime = a.next
+	}
+	a.n++
+	a.next = a.next.Add(secondsToDuration(seg.Duration))
+}
+
+// discontinuity handles the discontinuity set for the already
+// appended segment.
+func (a *autoProgramDateTime) discontinuity(seg *MediaSegment) {
+	if !a.mode.ResetOnDiscontinuity {
+		return
+	}
+	if seg.ProgramDateTime.IsZero() {
+		seg.ProgramDateTime = a.next.Add(-secondsToDuration(seg.Duration))
+	}
+	a.n = 1
+}
+
+// SetKeyRotation enables assigning of new key sets to the segments
+// appended after the call. Segments which get keys with SetKey or
+// SetKeys restart counting of the rotation period from them. Nil value
+// disables rotation.
+func (p *MediaPlaylist) SetKeyRotation(rotation *KeyRotation) error {
+	if rotation == nil {
+		p.rotation = nil
+		return nil
+	}
+	if rotation.Keys == nil {
+		return errors.New("key rotation requires the function of keys")
+	}
+	if rotation.Every == 0 && rotation.Interval <= 0 {
+		return errors.New("key rotation requires the period")
+	}
+	p.rotation = &keyRotation{mode: *rotation}
+	return nil
+}
+
+// keyRotation keeps the state of key rotation.
+type keyRotation struct {
+	mode    KeyRotation
+	n       uint          // number of segments since the last key
+	elapsed float64       // media time since the last key
+	key     *Key          // the last key seen by the rotation
+	last    *MediaSegment // the last appended segment
+}
+
+func (r *keyRotation) apply(p *MediaPlaylist, seg *MediaSegment) error {
+	if r.last != nil && r.last.Key != nil && r.last.Key != r.key {
+		// the key was set for the previous segment after it was appended
+		r.key, r.n, r.elapsed = r.last.Key, 1, r.last.Duration
+	}
+	if seg.Key == nil && (r.last == nil ||
+		r.mode.Every > 0 && r.n >= r.mode.Every ||
+		r.mode.Interval > 0 && r.elapsed >= r.mode.Interval) {
+		keys, err := r.mode.Keys(seg.SeqId)
+		if err != nil {
+			return err
+		}
+		if err = checkKeySet(keys); err != nil {
+			return err
+		}
+		if hasKeyformat(keys) {
+			version(&p.ver, 5)
+		}
+		seg.Key, seg.Keys = keys[0], append([]*Key(nil), keys[1:]...)
+	}
+	if seg.Key != nil {
+		r.key, r.n, r.elapsed = seg.Key, 0, 0
+	}
+	r.n++
+	r.elapsed += seg.Duration
+	r.last = seg
+	return nil
+}
+
 // SetCustomTag sets the provided tag on the media playlist for its
 // TagName.
 func (p *MediaPlaylist) SetCustomTag(tag CustomTag) {
@@ -901,11 +1313,18 @@ func (p *MediaPlaylist) WinSize() uint {
 	return p.winsize
 }
 
-// SetWinSize overwrites the playlist's window size.
+// SetWinSize overwrites the playlist's window size. The oldest segments
+// of a live playlist which don't fit the new window are removed.
 func (p *MediaPlaylist) SetWinSize(winsize uint) error {
 	if winsize > p.capacity {
 		return errors.New("capacity must be greater than winsize or equal")
 	}
 	p.winsize = winsize
+	// live playlist keeps only the last winsize segments
+	for !p.Closed && winsize > 0 && p.count > winsize {
+		if err := p.Remove(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
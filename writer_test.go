@@ -214,6 +214,54 @@ func TestSetSCTE35(t *testing.T) {
 // Create new media playlist
 // Add segment to media playlist
 // Set SCTE
+// Check START-DATE and IDs of SCTE-35 date ranges.
+func TestEncodeSCTE35DateRange(t *testing.T) {
+	p, e := NewMediaPlaylist(0, 4)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	for i := 0; i < 4; i++ {
+		_ = p.Append(fmt.Sprintf("test%d.ts", i), 5, "")
+	}
+	p.Segments[1].SCTE = &SCTE{Syntax: SCTE35_DATERANGE, CueType: SCTE35Cue_Start, Cue: "0xFC01", Time: 10}
+	p.Segments[3].SCTE = &SCTE{Syntax: SCTE35_DATERANGE, CueType: SCTE35Cue_End, Cue: "0xFC02", Time: 10}
+
+	// without PROGRAM-DATE-TIME the cues fall back to the syntax without dates
+	out := p.String()
+	if strings.Contains(out, "#EXT-X-DATERANGE") ||
+		!strings.Contains(out, `#EXT-SCTE35:CUE="0xFC01",TIME=10`+"\n") ||
+		!strings.Contains(out, `#EXT-SCTE35:CUE="0xFC02",TIME=10`+"\n") {
+		t.Errorf("Unexpected cues without program date time:\n%s", out)
+	}
+
+	p.Segments[0].ProgramDateTime = time.Date(2020, 1, 2, 3, 4, 0, 0, time.UTC)
+	p.ResetCache()
+	out = p.String()
+	for _, expected := range []string{
+		`#EXT-X-DATERANGE:ID="splice-1",START-DATE="2020-01-02T03:04:05Z",PLANNED-DURATION=10,SCTE35-OUT=0xFC01` + "\n",
+		`#EXT-X-DATERANGE:ID="splice-1",START-DATE="2020-01-02T03:04:05Z",DURATION=10,SCTE35-IN=0xFC02` + "\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Playlist does not contain %s\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, `ID=""`) {
+		t.Errorf("Empty ID is written:\n%s", out)
+	}
+
+	// the cue-in after the cue-out left the window gets START-DATE
+	// back from its duration
+	_ = p.Remove()
+	_ = p.Remove()
+	p.Segments[2].ProgramDateTime = time.Date(2020, 1, 2, 3, 4, 10, 0, time.UTC)
+	p.Segments[3].SCTE.ID = "break"
+	p.ResetCache()
+	expected := `#EXT-X-DATERANGE:ID="break",START-DATE="2020-01-02T03:04:05Z",DURATION=10,SCTE35-IN=0xFC02` + "\n"
+	if out = p.String(); !strings.Contains(out, expected) {
+		t.Errorf("Playlist does not contain %s\n%s", expected, out)
+	}
+}
+
 func TestSetSCTEForMediaPlaylist(t *testing.T) {
 	tests := []struct {
 		Cue      string
@@ -306,6 +354,82 @@ func TestSetDefaultKeyForMediaPlaylist(t *testing.T) {
 	}
 }
 
+// Create new media playlist
+// Set default key set and key sets of segments
+func TestSetKeysForMediaPlaylist(t *testing.T) {
+	p, e := NewMediaPlaylist(4, 4)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	fairplay := &Key{Method: "SAMPLE-AES", URI: "skd://key1", Keyformat: "com.apple.streamingkeydelivery", Keyformatversions: "1"}
+	widevine := &Key{Method: "SAMPLE-AES", URI: "data:text/plain;base64,AAAA", Keyformat: "urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed"}
+	if e = p.SetDefaultKeys(fairplay, widevine); e != nil {
+		t.Fatalf("Set default keys failed: %s", e)
+	}
+	if p.ver != 5 {
+		t.Errorf("Set keys playlist version: %v, expected: 5", p.ver)
+	}
+	if e = p.SetKeys(fairplay); e == nil {
+		t.Error("Expected error for keys of empty playlist")
+	}
+	for i := 0; i < 4; i++ {
+		_ = p.Append(fmt.Sprintf("test%d.ts", i), 5.0, "")
+		switch i {
+		case 1:
+			// the same set in another order
+			_ = p.SetKeys(widevine, fairplay)
+		case 2:
+			_ = p.SetKeys(fairplay)
+		case 3:
+			_ = p.SetKey("NONE", "", "", "", "")
+		}
+	}
+	expected := `#EXT-X-VERSION:5
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="skd://key1",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="data:text/plain;base64,AAAA",KEYFORMAT="urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed"
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-TARGETDURATION:5
+#EXTINF:5.000,
+test0.ts
+#EXTINF:5.000,
+test1.ts
+#EXT-X-KEY:METHOD=SAMPLE-AES,URI="skd://key1",KEYFORMAT="com.apple.streamingkeydelivery",KEYFORMATVERSIONS="1"
+#EXTINF:5.000,
+test2.ts
+#EXT-X-KEY:METHOD=NONE
+#EXTINF:5.000,
+test3.ts
+`
+	if out := p.String(); !strings.Contains(out, expected) {
+		t.Errorf("Media playlist did not contain:\n%s\nMedia Playlist:\n%v", expected, out)
+	}
+}
+
+// Check that sets with nil keys are rejected.
+func TestSetKeysWithNilKey(t *testing.T) {
+	p, e := NewMediaPlaylist(1, 1)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	key := &Key{Method: "SAMPLE-AES", URI: "skd://key1", Keyformat: "com.apple.streamingkeydelivery"}
+	if e = p.SetDefaultKeys(key, nil); e == nil {
+		t.Error("Expected error for nil default key")
+	}
+	if e = p.SetDefaultKeys(nil, key); e == nil {
+		t.Error("Expected error for nil default key")
+	}
+	if p.Key != nil || p.Keys != nil {
+		t.Errorf("Default keys must not be changed: %v, %v", p.Key, p.Keys)
+	}
+	_ = p.Append("test0.ts", 5.0, "")
+	if e = p.SetKeys(key, nil); e == nil {
+		t.Error("Expected error for nil key")
+	}
+	if seg := p.Segments[0]; seg.Key != nil || seg.Keys != nil {
+		t.Errorf("Segment keys must not be changed: %v, %v", seg.Key, seg.Keys)
+	}
+}
+
 // Create new media playlist
 // Set default map
 func TestSetDefaultMapForMediaPlaylist(t *testing.T) {
@@ -361,6 +485,10 @@ func TestEncodeMediaPlaylistWithDefaultMap(t *testing.T) {
 	if e != nil {
 		t.Errorf("Add 1st segment to a media playlist failed: %s", e)
 	}
+	e = p.Append("test02.ts", 5.0, "")
+	if e != nil {
+		t.Errorf("Add 2nd segment to a media playlist failed: %s", e)
+	}
 	e = p.SetMap("https://notencoded.com", 1000*1024, 1024*1024)
 	if e != nil {
 		t.Errorf("Set map to segment failed: %s", e)
@@ -372,9 +500,12 @@ func TestEncodeMediaPlaylistWithDefaultMap(t *testing.T) {
 		t.Fatalf("Media playlist did not contain: %s\nMedia Playlist:\n%v", expected, encoded)
 	}
 
-	ignored := `EXT-X-MAP:URI="https://notencoded.com"`
-	if strings.Contains(encoded, ignored) {
-		t.Fatalf("Media playlist contains non default map: %s\nMedia Playlist:\n%v", ignored, encoded)
+	// the map of the segment overrides the default map
+	expected = `#EXT-X-MAP:URI="https://notencoded.com",BYTERANGE=1024000@1048576
+#EXTINF:5.000,
+test02.ts`
+	if !strings.Contains(encoded, expected) {
+		t.Fatalf("Media playlist did not contain: %s\nMedia Playlist:\n%v", expected, encoded)
 	}
 }
 
@@ -659,6 +790,285 @@ func TestMediaSetWinSize(t *testing.T) {
 	}
 }
 
+// Check that discontinuity sequence follows discontinuities leaving
+// the window of a long-running live playlist.
+func TestMediaSlideDiscontinuitySeq(t *testing.T) {
+	p, e := NewMediaPlaylist(3, 5)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	var discontinuities uint64
+	for i := 0; i < 1000; i++ {
+		p.Slide(fmt.Sprintf("test%d.ts", i), 6.0, "")
+		if i%7 == 0 {
+			if e = p.SetDiscontinuity(); e != nil {
+				t.Fatal(e)
+			}
+		}
+		// segments with the discontinuity before the first one in the window
+		discontinuities = 0
+		for j := 0; j <= i-int(p.Count()); j++ {
+			if j%7 == 0 {
+				discontinuities++
+			}
+		}
+		if p.DiscontinuitySeq != discontinuities {
+			t.Fatalf("Segment %d: expected discontinuity sequence %d, got %d", i, discontinuities, p.DiscontinuitySeq)
+		}
+		if p.SeqNo != p.First().SeqId {
+			t.Fatalf("Segment %d: media sequence %d differs from the first segment %d", i, p.SeqNo, p.First().SeqId)
+		}
+	}
+	if !strings.Contains(p.String(), fmt.Sprintf("#EXT-X-DISCONTINUITY-SEQUENCE:%d\n", discontinuities)) {
+		t.Errorf("Discontinuity sequence is not encoded:\n%s", p)
+	}
+}
+
+// Check that discontinuity sequence is kept when the window shrinks.
+func TestMediaSetWinSizeDiscontinuitySeq(t *testing.T) {
+	p, e := NewMediaPlaylist(5, 5)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	for i := 0; i < 5; i++ {
+		_ = p.Append(fmt.Sprintf("test%d.ts", i), 6.0, "")
+		if i == 1 || i == 3 {
+			_ = p.SetDiscontinuity()
+		}
+	}
+	if e = p.SetWinSize(2); e != nil {
+		t.Fatal(e)
+	}
+	if p.Count() != 2 || p.SeqNo != 3 || p.DiscontinuitySeq != 1 {
+		t.Errorf("Unexpected playlist after shrinking: %d/%d/%d", p.Count(), p.SeqNo, p.DiscontinuitySeq)
+	}
+	if e = p.Remove(); e != nil {
+		t.Fatal(e)
+	}
+	if p.SeqNo != 4 || p.DiscontinuitySeq != 2 {
+		t.Errorf("Unexpected playlist after removal: %d/%d", p.SeqNo, p.DiscontinuitySeq)
+	}
+}
+
+// Check that the key and the map in force for the first segment of a
+// live window are emitted after the segment which carried them is
+// removed.
+func TestMediaSlideKeepsKeyAndMap(t *testing.T) {
+	p, e := NewMediaPlaylist(3, 3)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	for i := 0; i < 10; i++ {
+		p.Slide(fmt.Sprintf("test%d.ts", i), 6.0, "")
+		switch i {
+		case 0:
+			_ = p.SetMap("init1.mp4", 0, 0)
+		case 2:
+			_ = p.SetKey("AES-128", "key1.bin", "", "", "")
+		case 6:
+			_ = p.SetKey("AES-128", "key2.bin", "", "", "")
+		}
+		out := p.String()
+		if !strings.Contains(out, "#EXT-X-MAP:URI=\"init1.mp4\"\n") {
+			t.Fatalf("Segment %d: map is lost:\n%s", i, out)
+		}
+		if i >= 2 && !strings.Contains(out, "#EXT-X-KEY:") {
+			t.Fatalf("Segment %d: key is lost:\n%s", i, out)
+		}
+	}
+	// the key and the map in force become the defaults of the playlist
+	expected := `#EXT-X-KEY:METHOD=AES-128,URI="key2.bin"
+#EXT-X-MAP:URI="init1.mp4"
+#EXT-X-MEDIA-SEQUENCE:7
+#EXT-X-TARGETDURATION:6
+#EXTINF:6.000,
+test7.ts
+#EXTINF:6.000,
+test8.ts
+#EXTINF:6.000,
+test9.ts
+`
+	if !strings.HasSuffix(p.String(), expected) {
+		t.Errorf("Unexpected window:\n%s\nexpected suffix:\n%s", p, expected)
+	}
+}
+
+// Check that the defaults of decoded playlist follow the head after
+// removal of segments with another key and map.
+func TestMediaRemoveKeepsKeyAndMapOfHead(t *testing.T) {
+	p, e := NewMediaPlaylist(0, 2)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	e = p.DecodeFrom(bytes.NewBufferString(`#EXTM3U
+#EXT-X-VERSION:6
+#EXT-X-TARGETDURATION:6
+#EXT-X-KEY:METHOD=AES-128,URI="k1"
+#EXT-X-MAP:URI="init1.mp4"
+#EXTINF:6.000,
+test0.ts
+#EXT-X-DISCONTINUITY
+#EXT-X-KEY:METHOD=AES-128,URI="k2"
+#EXT-X-MAP:URI="init2.mp4"
+#EXTINF:6.000,
+test1.ts
+`), true)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if e = p.Remove(); e != nil {
+		t.Fatal(e)
+	}
+	out := p.String()
+	if strings.Contains(out, `"k1"`) || strings.Contains(out, `"init1.mp4"`) {
+		t.Errorf("Key or map of the removed segment is written:\n%s", out)
+	}
+	expected := `#EXT-X-KEY:METHOD=AES-128,URI="k2"
+#EXT-X-MAP:URI="init2.mp4"
+`
+	if !strings.Contains(out, expected) || strings.Count(out, "#EXT-X-KEY:") != 1 || strings.Count(out, "#EXT-X-MAP:") != 1 {
+		t.Errorf("Unexpected key and map:\n%s\nexpected:\n%s", out, expected)
+	}
+}
+
+// Check automatic program date and time of each Nth segment with the
+// reset on discontinuity.
+func TestMediaAutoProgramDateTime(t *testing.T) {
+	p, e := NewMediaPlaylist(0, 10)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.SetAutoProgramDateTime(&AutoProgramDateTime{Start: start, Every: 3, ResetOnDiscontinuity: true})
+	for i := 0; i < 8; i++ {
+		_ = p.Append(fmt.Sprintf("test%d.ts", i), 2.5, "")
+		if i == 4 {
+			_ = p.SetDiscontinuity()
+		}
+	}
+	_ = p.AppendSegment(&MediaSegment{URI: "test8.ts", Duration: 2.5, Discontinuity: true})
+	expected := map[int]time.Duration{
+		0: 0,
+		3: 7500 * time.Millisecond,
+		4: 10 * time.Second, // discontinuity
+		7: 17500 * time.Millisecond,
+		8: 20 * time.Second, // discontinuity
+	}
+	for i := 0; i < 9; i++ {
+		pdt := p.Segments[i].ProgramDateTime
+		offset, ok := expected[i]
+		if ok && !pdt.Equal(start.Add(offset)) || !ok && !pdt.IsZero() {
+			t.Errorf("Segment %d: unexpected program date time %v", i, pdt)
+		}
+	}
+
+	p.SetAutoProgramDateTime(nil)
+	_ = p.Append("test9.ts", 2.5, "")
+	if !p.Segments[9].ProgramDateTime.IsZero() {
+		t.Error("Program date time must not be set in disabled mode")
+	}
+}
+
+// Check automatic program date and time of each segment counted from
+// explicitly set time.
+func TestMediaAutoProgramDateTimeEachSegment(t *testing.T) {
+	p, e := NewMediaPlaylist(3, 3)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.SetAutoProgramDateTime(&AutoProgramDateTime{Start: start})
+	p.Slide("test0.ts", 4.0, "")
+	_ = p.AppendSegment(&MediaSegment{URI: "test1.ts", Duration: 4.0, ProgramDateTime: start.Add(time.Minute)})
+	p.Slide("test2.ts", 4.0, "")
+	p.Slide("test3.ts", 4.0, "")
+	expected := `#EXT-X-PROGRAM-DATE-TIME:2020-01-01T00:01:00Z
+#EXTINF:4.000,
+test1.ts
+#EXT-X-PROGRAM-DATE-TIME:2020-01-01T00:01:04Z
+#EXTINF:4.000,
+test2.ts
+#EXT-X-PROGRAM-DATE-TIME:2020-01-01T00:01:08Z
+#EXTINF:4.000,
+test3.ts
+`
+	if !strings.HasSuffix(p.String(), expected) {
+		t.Errorf("Unexpected playlist:\n%s\nexpected suffix:\n%s", p, expected)
+	}
+}
+
+// Check target duration rounding to the nearest integer and its
+// recomputation after long segments left the window.
+func TestMediaTargetDurationPolicy(t *testing.T) {
+	p, e := NewMediaPlaylist(3, 3)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	p.SetTargetDurationRounding(TargetDurationRound)
+	for i, duration := range []float64{6.4, 10.2, 6.0, 6.0, 6.0} {
+		p.Slide(fmt.Sprintf("test%d.ts", i), duration, "")
+	}
+	if p.TargetDuration != 10 {
+		t.Errorf("Expected target duration 10, got: %v", p.TargetDuration)
+	}
+	if e = p.CheckTargetDuration(); e != nil {
+		t.Errorf("Unexpected error: %v", e)
+	}
+	if target := p.RecomputeTargetDuration(); target != 6 || !strings.Contains(p.String(), "#EXT-X-TARGETDURATION:6\n") {
+		t.Errorf("Expected recomputed target duration 6, got: %v", target)
+	}
+	p.SetTargetDurationRounding(TargetDurationCeil)
+	p.Slide("test5.ts", 6.4, "")
+	if p.TargetDuration != 7 {
+		t.Errorf("Expected target duration 7, got: %v", p.TargetDuration)
+	}
+}
+
+// Check that short segments don't round target duration to zero.
+func TestMediaTargetDurationRoundShortSegment(t *testing.T) {
+	p, e := NewMediaPlaylist(0, 1)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	p.SetTargetDurationRounding(TargetDurationRound)
+	if e = p.Append("test0.ts", 0.4, ""); e != nil {
+		t.Fatal(e)
+	}
+	if p.TargetDuration != 1 || !strings.Contains(p.String(), "#EXT-X-TARGETDURATION:1\n") {
+		t.Errorf("Expected target duration 1, got: %v", p.TargetDuration)
+	}
+	if target := p.RecomputeTargetDuration(); target != 1 {
+		t.Errorf("Expected recomputed target duration 1, got: %v", target)
+	}
+	if e = p.CheckTargetDuration(); e != nil {
+		t.Errorf("Unexpected error: %v", e)
+	}
+}
+
+// Check the report of segments exceeding the target duration.
+func TestMediaCheckTargetDuration(t *testing.T) {
+	p, e := NewMediaPlaylist(0, 3)
+	if e != nil {
+		t.Fatalf("Create media playlist failed: %s", e)
+	}
+	_ = p.Append("test0.ts", 6.0, "")
+	_ = p.Append("test1.ts", 8.5, "")
+	_ = p.Append("test2.ts", 6.0, "")
+	p.TargetDuration = 6
+	e = p.CheckTargetDuration()
+	terr, ok := e.(*TargetDurationError)
+	if !ok || len(terr.Segments) != 1 || terr.Segments[0].URI != "test1.ts" {
+		t.Fatalf("Unexpected error: %v", e)
+	}
+	if e.Error() != "segments 1 exceed target duration 6" {
+		t.Errorf("Unexpected error message: %s", e)
+	}
+	p.TargetDuration = 9
+	if e = p.CheckTargetDuration(); e != nil {
+		t.Errorf("Unexpected error: %v", e)
+	}
+}
+
 func TestIndependentSegments(t *testing.T) {
 	m := NewMasterPlaylist()
 	if m.IndependentSegments() != false {
@@ -833,6 +1243,29 @@ func TestNewMasterPlaylistWithClosedCaptionEqNone(t *testing.T) {
 	}
 }
 
+// Create new master playlist with closed captions rendition
+func TestNewMasterPlaylistWithInstreamId(t *testing.T) {
+	m := NewMasterPlaylist()
+	cc := &Alternative{
+		GroupId:    "cc",
+		Type:       "CLOSED-CAPTIONS",
+		Name:       "English",
+		Language:   "en",
+		InstreamId: "CC1",
+		Channels:   "2",
+	}
+	p, err := NewMediaPlaylist(1, 1)
+	if err != nil {
+		t.Fatalf("Create media playlist failed: %s", err)
+	}
+	m.Append("chunklist1.m3u8", p, VariantParams{Bandwidth: 800000, Captions: "cc", Alternatives: []*Alternative{cc}})
+
+	expected := `CHANNELS="2",INSTREAM-ID="CC1"`
+	if !strings.Contains(m.String(), expected) {
+		t.Fatalf("Master playlist did not contain: %s\nMaster Playlist:\n%v", expected, m.String())
+	}
+}
+
 // Create new master playlist with params
 // Add media playlist
 func TestNewMasterPlaylistWithParams(t *testing.T) {